@@ -0,0 +1,255 @@
+// Package bip21 parses and builds payment URIs (BIP21), e.g. bitcoin:address?amount=0.1
+package bip21
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pavel-main/bws-go/cashaddr"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/models"
+)
+
+// SchemeBTC is URI scheme of bitcoin payments, BCH uses CashAddr prefix of network
+const SchemeBTC = "bitcoin"
+
+const (
+	paramAmount  = "amount"
+	paramLabel   = "label"
+	paramMessage = "message"
+	paramRequest = "r"
+	prefixReq    = "req-"
+	satoshis     = 100000000
+)
+
+// URI is payment request of BIP21 URI
+type URI struct {
+	Address        string `json:"address,omitempty"` // Optional when payment request URL is set
+	Amount         int64  `json:"amount,omitempty"`  // Amount in satoshis, zero if not specified
+	Label          string `json:"label,omitempty"`
+	Message        string `json:"message,omitempty"`
+	PaymentRequest string `json:"r,omitempty"` // Payment protocol request URL (BIP72)
+}
+
+// Scheme returns URI scheme of configured coin and network
+func Scheme(cfg *config.Config) (string, error) {
+	if cfg.Coin == config.CoinBCH {
+		return cashaddr.Prefix(cfg.NetParams())
+	}
+
+	return SchemeBTC, nil
+}
+
+// Parse decodes URI and validates its address for configured coin and network
+func Parse(raw string, cfg *config.Config) (*URI, error) {
+	scheme, err := Scheme(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	raw = strings.TrimSpace(raw)
+	idx := strings.Index(raw, ":")
+	if idx == -1 || !strings.EqualFold(raw[:idx], scheme) {
+		return nil, fmt.Errorf("URI scheme must be %s", scheme)
+	}
+
+	rest := strings.TrimPrefix(raw[idx+1:], "//")
+	query := ""
+	if idx := strings.Index(rest, "?"); idx != -1 {
+		rest, query = rest[:idx], rest[idx+1:]
+	}
+
+	result := &URI{}
+	if rest != "" {
+		if result.Address, err = url.PathUnescape(rest); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+
+		// Unlike form encoding, BIP21 keeps '+' as is
+		key, value := pair, ""
+		if idx := strings.Index(pair, "="); idx != -1 {
+			key, value = pair[:idx], pair[idx+1:]
+		}
+
+		if key, err = url.PathUnescape(key); err != nil {
+			return nil, fmt.Errorf("Invalid URI parameters: %v", err)
+		}
+
+		if value, err = url.PathUnescape(value); err != nil {
+			return nil, fmt.Errorf("Invalid URI parameters: %v", err)
+		}
+
+		if seen[key] {
+			return nil, fmt.Errorf("Duplicate URI parameter: %s", key)
+		}
+
+		seen[key] = true
+		switch key {
+		case paramAmount:
+			if result.Amount, err = ParseAmount(value); err != nil {
+				return nil, err
+			}
+		case paramLabel:
+			result.Label = value
+		case paramMessage:
+			result.Message = value
+		case paramRequest:
+			result.PaymentRequest = value
+		default:
+			// Unknown required parameters must not be ignored
+			if strings.HasPrefix(key, prefixReq) {
+				return nil, fmt.Errorf("Unsupported required URI parameter: %s", key)
+			}
+		}
+	}
+
+	if err := result.Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Validate checks that URI has valid address or payment request URL
+func (u *URI) Validate(cfg *config.Config) error {
+	if u.Address == "" && u.PaymentRequest == "" {
+		return errors.New("URI address not specified")
+	}
+
+	if u.Address != "" {
+		if err := ValidateAddress(u.Address, cfg); err != nil {
+			return err
+		}
+	}
+
+	if u.Amount < 0 {
+		return errors.New("URI amount must not be negative")
+	}
+
+	if u.PaymentRequest != "" {
+		parsed, err := url.Parse(u.PaymentRequest)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("Invalid payment request URL: %s", u.PaymentRequest)
+		}
+	}
+
+	return nil
+}
+
+// Encode validates URI and encodes it with scheme of configured coin and network
+func (u *URI) Encode(cfg *config.Config) (string, error) {
+	if err := u.Validate(cfg); err != nil {
+		return "", err
+	}
+
+	scheme, err := Scheme(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	return u.encode(scheme), nil
+}
+
+func (u *URI) encode(scheme string) string {
+	// CashAddr prefix is the scheme itself
+	address := u.Address
+	if idx := strings.LastIndex(address, ":"); idx != -1 && strings.EqualFold(address[:idx], scheme) {
+		address = address[idx+1:]
+	}
+
+	params := []string{}
+	if u.Amount != 0 {
+		params = append(params, paramAmount+"="+FormatAmount(u.Amount))
+	}
+
+	for _, param := range [][2]string{{paramLabel, u.Label}, {paramMessage, u.Message}, {paramRequest, u.PaymentRequest}} {
+		if param[1] != "" {
+			params = append(params, param[0]+"="+escape(param[1]))
+		}
+	}
+
+	result := scheme + ":" + address
+	if len(params) != 0 {
+		result += "?" + strings.Join(params, "&")
+	}
+
+	return result
+}
+
+// Outputs converts URI to proposal outputs, payment request URLs must be fetched instead
+func (u *URI) Outputs() ([]*models.TxOutput, error) {
+	if u.Address == "" {
+		return nil, errors.New("URI has payment request URL only")
+	}
+
+	if u.Amount <= 0 {
+		return nil, errors.New("URI amount not specified")
+	}
+
+	output := models.NewTxOutput(u.Amount, u.Address)
+	if u.Message != "" {
+		message := u.Message
+		output.Message = &message
+	}
+
+	return []*models.TxOutput{output}, nil
+}
+
+// ValidateAddress checks that address belongs to configured coin and network,
+// BCH addresses are accepted in CashAddr and legacy format
+func ValidateAddress(address string, cfg *config.Config) error {
+	_, err := models.DecodeAddress(address, cfg.Coin, cfg.NetParams())
+	return err
+}
+
+// ParseAmount converts decimal amount in coins to satoshis
+func ParseAmount(amount string) (int64, error) {
+	whole, fraction := amount, ""
+	if idx := strings.Index(amount, "."); idx != -1 {
+		whole, fraction = amount[:idx], amount[idx+1:]
+	}
+
+	if (whole == "" && fraction == "") || len(fraction) > 8 || !digits(whole) || !digits(fraction) {
+		return 0, fmt.Errorf("Invalid URI amount: %s", amount)
+	}
+
+	fraction += strings.Repeat("0", 8-len(fraction))
+	coins, err := strconv.ParseInt("0"+whole, 10, 64)
+	if err != nil || coins > 21000000 {
+		return 0, fmt.Errorf("Invalid URI amount: %s", amount)
+	}
+
+	sats, _ := strconv.ParseInt(fraction, 10, 64)
+	return coins*satoshis + sats, nil
+}
+
+// FormatAmount converts satoshis to decimal amount in coins without trailing zeros
+func FormatAmount(amount int64) string {
+	result := fmt.Sprintf("%d.%08d", amount/satoshis, amount%satoshis)
+	return strings.TrimSuffix(strings.TrimRight(result, "0"), ".")
+}
+
+func digits(value string) bool {
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// escape percent-encodes parameter value, spaces as %20
+func escape(value string) string {
+	return strings.Replace(url.QueryEscape(value), "+", "%20", -1)
+}
@@ -0,0 +1,136 @@
+package bip21
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pavel-main/bws-go/cashaddr"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAddress = "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"
+
+func TestParse(t *testing.T) {
+	cfg := config.NewPublicTestnet()
+	uri, err := Parse("bitcoin:"+testAddress+"?amount=0.0012&label=Luke-Jr&message=Donation%20for%20project%20xyz", cfg)
+	assert.NoError(t, err, "should parse URI")
+	assert.Equal(t, &URI{Address: testAddress, Amount: 120000, Label: "Luke-Jr", Message: "Donation for project xyz"}, uri)
+
+	uri, err = Parse("BITCOIN:"+testAddress, cfg)
+	assert.NoError(t, err, "should accept scheme in upper case")
+	assert.Equal(t, int64(0), uri.Amount)
+
+	uri, err = Parse("bitcoin:?r=https://merchant.example/i/42", cfg)
+	assert.NoError(t, err, "should accept payment request without address")
+	assert.Equal(t, "https://merchant.example/i/42", uri.PaymentRequest)
+
+	uri, err = Parse("bitcoin:"+testAddress+"?label=Shop+Co&message=1%2B1", cfg)
+	assert.NoError(t, err, "should parse URI with plus sign")
+	assert.Equal(t, "Shop+Co", uri.Label, "should keep plus sign")
+	assert.Equal(t, "1+1", uri.Message)
+
+	uri, err = Parse("bitcoin:"+testAddress+"?somethingyoudontunderstand=50", cfg)
+	assert.NoError(t, err, "should ignore unknown optional parameters")
+
+	invalid := []string{
+		"bitcoincash:" + testAddress,
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+		"bitcoin:invalid",
+		"bitcoin:",
+		"bitcoin:" + testAddress + "?amount=1e3",
+		"bitcoin:" + testAddress + "?amount=-1",
+		"bitcoin:" + testAddress + "?amount=0.000000001",
+		"bitcoin:" + testAddress + "?amount=1&amount=2",
+		"bitcoin:" + testAddress + "?req-somethingyoudontunderstand=50",
+		"bitcoin:" + testAddress + "?r=ftp://merchant.example",
+		"bitcoin:" + testAddress + "?r=http://merchant.example/i/42",
+		"bitcoin:" + testAddress + "?label=%zz",
+	}
+
+	for _, raw := range invalid {
+		_, err := Parse(raw, cfg)
+		assert.Error(t, err, "should reject %s", raw)
+	}
+}
+
+func TestParseCashAddr(t *testing.T) {
+	cfg := config.NewCashPublic()
+	uri, err := Parse("bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a?amount=1.5", cfg)
+	assert.NoError(t, err, "should parse CashAddr URI")
+	assert.Equal(t, "qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", uri.Address)
+	assert.Equal(t, int64(150000000), uri.Amount)
+
+	_, err = Parse("bitcoin:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", cfg)
+	assert.Error(t, err, "should require BCH scheme")
+
+	_, err = Parse("bitcoincash:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", cfg)
+	assert.Error(t, err, "should reject segwit address")
+
+	testnet, _ := cashaddr.Encode(cashaddr.PrefixTestNet, cashaddr.P2PKH, bytes.Repeat([]byte{1}, 20))
+	_, err = Parse(testnet, cfg)
+	assert.Error(t, err, "should reject testnet address")
+
+	uri, err = Parse(testnet, config.NewCashPublicTestnet())
+	assert.NoError(t, err, "should use bchtest scheme on testnet")
+}
+
+func TestEncode(t *testing.T) {
+	cfg := config.NewPublicTestnet()
+	uri := &URI{Address: testAddress, Amount: 150000000, Label: "Shop & Co", Message: "Order 42"}
+	encoded, err := uri.Encode(cfg)
+	assert.NoError(t, err, "should encode URI")
+	assert.Equal(t, "bitcoin:"+testAddress+"?amount=1.5&label=Shop%20%26%20Co&message=Order%2042", encoded)
+
+	parsed, err := Parse(encoded, cfg)
+	assert.NoError(t, err, "should parse encoded URI")
+	assert.Equal(t, uri, parsed, "should round trip")
+
+	_, err = (&URI{Address: testAddress}).Encode(config.NewPublic())
+	assert.Error(t, err, "should validate address network")
+
+	bch := &URI{Address: "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", Amount: 1000}
+	encoded, err = bch.Encode(config.NewCashPublic())
+	assert.NoError(t, err)
+	assert.Equal(t, "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a?amount=0.00001", encoded, "should not repeat CashAddr prefix")
+}
+
+func TestOutputs(t *testing.T) {
+	outputs, err := (&URI{Address: testAddress, Amount: 1000, Message: "rent"}).Outputs()
+	assert.NoError(t, err, "should convert URI to outputs")
+	assert.Len(t, outputs, 1)
+	assert.Equal(t, int64(1000), outputs[0].Amount)
+	assert.Equal(t, testAddress, outputs[0].ToAddress)
+	assert.Equal(t, "rent", *outputs[0].Message)
+
+	_, err = (&URI{Address: testAddress}).Outputs()
+	assert.Error(t, err, "should require amount")
+
+	_, err = (&URI{PaymentRequest: "https://merchant.example/i/42"}).Outputs()
+	assert.Error(t, err, "should require address")
+}
+
+func TestAmount(t *testing.T) {
+	fixtures := map[string]int64{
+		"1":          100000000,
+		"0.1":        10000000,
+		".5":         50000000,
+		"20.3":       2030000000,
+		"0.00000001": 1,
+	}
+
+	for input, expected := range fixtures {
+		amount, err := ParseAmount(input)
+		assert.NoError(t, err, "should parse %s", input)
+		assert.Equal(t, expected, amount, "amounts should match for %s", input)
+	}
+
+	for _, input := range []string{"", ".", "1,5", "1.2.3", "21000001", "0.123456789"} {
+		_, err := ParseAmount(input)
+		assert.Error(t, err, "should fail on %s", input)
+	}
+
+	assert.Equal(t, "20.3", FormatAmount(2030000000))
+	assert.Equal(t, "10", FormatAmount(1000000000))
+	assert.Equal(t, "0.00000001", FormatAmount(1))
+}
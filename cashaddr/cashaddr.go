@@ -0,0 +1,188 @@
+// Package cashaddr encodes and decodes Bitcoin Cash addresses in CashAddr format
+package cashaddr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Address types
+const (
+	P2PKH byte = 0
+	P2SH  byte = 1
+)
+
+// Network prefixes
+const (
+	PrefixMainNet = "bitcoincash"
+	PrefixTestNet = "bchtest"
+	PrefixRegTest = "bchreg"
+)
+
+const (
+	charset        = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	checksumLength = 8
+	hashLength     = 20
+)
+
+// Address is decoded CashAddr address
+type Address struct {
+	Prefix string
+	Type   byte
+	Hash   []byte
+}
+
+// Prefix returns address prefix of network
+func Prefix(net *chaincfg.Params) (string, error) {
+	switch net.Name {
+	case chaincfg.MainNetParams.Name:
+		return PrefixMainNet, nil
+	case chaincfg.TestNet3Params.Name:
+		return PrefixTestNet, nil
+	case chaincfg.RegressionNetParams.Name:
+		return PrefixRegTest, nil
+	}
+
+	return "", fmt.Errorf("Unsupported network: %s", net.Name)
+}
+
+// Encode returns address with prefix, e.g. bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a
+func Encode(prefix string, addressType byte, hash []byte) (string, error) {
+	if len(hash) != hashLength {
+		return "", errors.New("Only 160-bit hashes are supported")
+	}
+
+	if addressType != P2PKH && addressType != P2SH {
+		return "", fmt.Errorf("Unknown address type: %d", addressType)
+	}
+
+	payload := convertBits(append([]byte{addressType << 3}, hash...), 8, 5, true)
+	checksum := checksum(prefix, payload)
+
+	var result strings.Builder
+	result.WriteString(prefix + ":")
+	for _, value := range append(payload, checksum...) {
+		result.WriteByte(charset[value])
+	}
+
+	return result.String(), nil
+}
+
+// Decode parses address, defaultPrefix is assumed when address has none
+func Decode(address, defaultPrefix string) (*Address, error) {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return nil, errors.New("Mixed case address")
+	}
+
+	address = strings.ToLower(address)
+	prefix := defaultPrefix
+	if idx := strings.LastIndex(address, ":"); idx != -1 {
+		prefix, address = address[:idx], address[idx+1:]
+	}
+
+	if len(address) <= checksumLength {
+		return nil, errors.New("Address too short")
+	}
+
+	values := []byte{}
+	for i := 0; i < len(address); i++ {
+		value := strings.IndexByte(charset, address[i])
+		if value == -1 {
+			return nil, fmt.Errorf("Invalid character %q", address[i])
+		}
+
+		values = append(values, byte(value))
+	}
+
+	payload := values[:len(values)-checksumLength]
+	if string(checksum(prefix, payload)) != string(values[len(payload):]) {
+		return nil, errors.New("Invalid checksum")
+	}
+
+	data := convertBits(payload, 5, 8, false)
+	if data == nil || len(data) != hashLength+1 {
+		return nil, errors.New("Invalid address payload")
+	}
+
+	// Version byte holds type in bits 3-6 and hash size in bits 0-2
+	version := data[0]
+	if version&0x87 != 0 {
+		return nil, fmt.Errorf("Unsupported address version: %d", version)
+	}
+
+	addressType := version >> 3
+	if addressType != P2PKH && addressType != P2SH {
+		return nil, fmt.Errorf("Unknown address type: %d", addressType)
+	}
+
+	return &Address{Prefix: prefix, Type: addressType, Hash: data[1:]}, nil
+}
+
+// String returns encoded address
+func (a *Address) String() string {
+	encoded, _ := Encode(a.Prefix, a.Type, a.Hash)
+	return encoded
+}
+
+func checksum(prefix string, payload []byte) []byte {
+	values := []byte{}
+	for i := 0; i < len(prefix); i++ {
+		values = append(values, prefix[i]&0x1f)
+	}
+
+	values = append(values, 0)
+	values = append(values, payload...)
+	values = append(values, make([]byte, checksumLength)...)
+
+	mod := polymod(values)
+	result := make([]byte, checksumLength)
+	for i := range result {
+		result[i] = byte((mod >> uint(5*(checksumLength-1-i))) & 0x1f)
+	}
+
+	return result
+}
+
+func polymod(values []byte) uint64 {
+	generators := []uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+	var c uint64 = 1
+	for _, value := range values {
+		c0 := c >> 35
+		c = ((c & 0x07ffffffff) << 5) ^ uint64(value)
+		for i, generator := range generators {
+			if (c0>>uint(i))&1 == 1 {
+				c ^= generator
+			}
+		}
+	}
+
+	return c ^ 1
+}
+
+// convertBits regroups data of fromBits-wide values into toBits-wide values
+func convertBits(data []byte, fromBits, toBits uint, pad bool) []byte {
+	var acc, bits uint
+	maxValue := uint(1<<toBits) - 1
+	result := []byte{}
+	for _, value := range data {
+		acc = acc<<fromBits | uint(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte((acc>>bits)&maxValue))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			result = append(result, byte((acc<<(toBits-bits))&maxValue))
+		}
+	} else if bits >= fromBits || (acc<<(toBits-bits))&maxValue != 0 {
+		return nil
+	}
+
+	return result
+}
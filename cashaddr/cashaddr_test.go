@@ -0,0 +1,58 @@
+package cashaddr
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	hash, _ := hex.DecodeString("76a04053bda0a88bda5177b86a15c3b29f559873")
+
+	address, err := Encode(PrefixMainNet, P2PKH, hash)
+	assert.NoError(t, err, "should encode P2PKH address")
+	assert.Equal(t, "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", address)
+
+	address, err = Encode(PrefixMainNet, P2SH, hash)
+	assert.NoError(t, err, "should encode P2SH address")
+	assert.Equal(t, "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq", address)
+
+	address, err = Encode(PrefixTestNet, P2PKH, hash)
+	assert.NoError(t, err, "should encode testnet address")
+	assert.Equal(t, "bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvqcw003ap", address)
+
+	_, err = Encode(PrefixMainNet, P2PKH, hash[:10])
+	assert.Error(t, err, "should reject short hash")
+}
+
+func TestDecode(t *testing.T) {
+	address, err := Decode("bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", PrefixMainNet)
+	assert.NoError(t, err, "should decode address")
+	assert.Equal(t, P2PKH, address.Type)
+	assert.Equal(t, "76a04053bda0a88bda5177b86a15c3b29f559873", hex.EncodeToString(address.Hash))
+
+	address, err = Decode("PPM2QSZNHKS23Z7629MMS6S4CWEF74VCWVN0H829PQ", PrefixMainNet)
+	assert.NoError(t, err, "should decode upper case address without prefix")
+	assert.Equal(t, P2SH, address.Type)
+	assert.Equal(t, "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq", address.String())
+
+	_, err = Decode("bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", PrefixMainNet)
+	assert.Error(t, err, "should reject address of other network")
+
+	_, err = Decode("bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6b", PrefixMainNet)
+	assert.Error(t, err, "should reject invalid checksum")
+
+	_, err = Decode("bitcoincash:Qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", PrefixMainNet)
+	assert.Error(t, err, "should reject mixed case")
+}
+
+func TestPrefix(t *testing.T) {
+	prefix, err := Prefix(&chaincfg.TestNet3Params)
+	assert.NoError(t, err)
+	assert.Equal(t, PrefixTestNet, prefix)
+
+	_, err = Prefix(&chaincfg.SimNetParams)
+	assert.Error(t, err, "should reject unknown network")
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec"
 	httpclient "github.com/ddliu/go-httpclient"
 	"github.com/pavel-main/bws-go/config"
 	"github.com/pavel-main/bws-go/credentials"
@@ -22,9 +23,11 @@ var clientVersion = "bws-go v2.0.0"
 
 // Client is responsible for HTTP requests and signatures
 type Client struct {
-	cfg    *config.Config
-	client *httpclient.HttpClient
-	keys   *credentials.Credentials
+	cfg       *config.Config
+	client    *httpclient.HttpClient
+	keys      *credentials.Credentials
+	frozen    *FreezeList
+	walletKey *btcec.PublicKey
 }
 
 // New creates new client instance based on Config, HttpClient and Credentials
@@ -32,14 +35,32 @@ func New(cfg *config.Config, chain *credentials.Credentials) (*Client, error) {
 	c := new(Client)
 	c.cfg = cfg
 	c.keys = chain
-	c.client = httpclient.Defaults(httpclient.Map{
+	options := httpclient.Map{
 		httpclient.OPT_DEBUG:             cfg.Debug,
 		httpclient.OPT_CONNECTTIMEOUT_MS: cfg.Timeout,
 		httpclient.OPT_TIMEOUT_MS:        cfg.Deadline,
+		httpclient.OPT_UNSAFE_TLS:        cfg.InsecureTLS,
 		"Content-Type":                   "application/json",
 		"Accept":                         "application/json",
 		"User-Agent":                     clientVersion,
-	})
+	}
+
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.ProxyURL())
+		if err != nil {
+			return nil, err
+		}
+
+		// HttpClient adds scheme itself, credentials must be kept
+		host := proxy.Host
+		if proxy.User != nil {
+			host = proxy.User.String() + "@" + host
+		}
+
+		options[httpclient.OPT_PROXY] = host
+	}
+
+	c.client = httpclient.NewHttpClient().Defaults(options)
 	return c, nil
 }
 
@@ -116,6 +137,8 @@ func (c *Client) JoinWallet(name, secret string) (*models.WalletJoin, error) {
 		return nil, err
 	}
 
+	c.walletKey = privateKey.PubKey()
+
 	response := &models.WalletJoin{}
 	if err := json.Unmarshal(bytes, response); err != nil {
 		return nil, err
@@ -155,6 +178,19 @@ func (c *Client) GetMaxInfo(feeLevel string, feePerKb uint64) (*models.MaxInfo,
 		params["feePerKb"] = strconv.FormatUint(feePerKb, 10)
 	}
 
+	return c.getMaxInfo(params)
+}
+
+func (c *Client) getMaxInfo(params map[string]string) (*models.MaxInfo, error) {
+	frozen, err := c.frozenOutpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(frozen) != 0 {
+		params["utxosToExclude"] = strings.Join(frozen, ",")
+	}
+
 	bytes, err := c.doGetRequest("/v1/sendmaxinfo/", params)
 	if err != nil {
 		return nil, err
@@ -213,6 +249,27 @@ func (c *Client) SavePreferences(payload map[string]interface{}) error {
 	return nil
 }
 
+// UpdatePreferences validates and saves fields set in update, then subscribes listed push devices
+func (c *Client) UpdatePreferences(update *models.PreferencesUpdate) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
+
+	if payload := update.Payload(); len(payload) != 0 {
+		if err := c.SavePreferences(payload); err != nil {
+			return err
+		}
+	}
+
+	for _, push := range update.Push {
+		if err := c.PushNotificationsSubscribe(push.Platform, push.Token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetBalance returns wallet balance
 func (c *Client) GetBalance(twoStep bool) (*models.Balance, error) {
 	params := map[string]string{
@@ -399,6 +456,15 @@ func (c *Client) CreateTxProposal(outputs []*models.TxOutput, feeLevel string, d
 		"dryRun":   dryRun,
 	}
 
+	frozen, err := c.frozenOutpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(frozen) != 0 {
+		payload["utxosToExclude"] = frozen
+	}
+
 	bytes, err := c.doPostRequest("/v2/txproposals/", payload)
 	if err != nil {
 		return nil, err
@@ -409,6 +475,10 @@ func (c *Client) CreateTxProposal(outputs []*models.TxOutput, feeLevel string, d
 		return nil, err
 	}
 
+	if err := c.checkFrozenProposal(response, dryRun); err != nil {
+		return nil, err
+	}
+
 	return response, nil
 }
 
@@ -439,6 +509,14 @@ func (c *Client) PublishTxProposal(txp *models.TxProposal) (*models.TxProposal,
 
 // SignTxProposal signs transaction proposal
 func (c *Client) SignTxProposal(txp *models.TxProposal) (*models.TxProposal, error) {
+	if err := c.checkFrozen(txp.Inputs); err != nil {
+		return nil, err
+	}
+
+	if err := c.VerifyTxProposal(txp); err != nil {
+		return nil, err
+	}
+
 	signatures := []string{}
 
 	for idx, input := range txp.Inputs {
@@ -645,12 +723,21 @@ func (c *Client) doRequest(method, path string, payload map[string]interface{})
 		return nil, err
 	}
 
-	reqBody := &bytes.Reader{}
-	if method != "GET" {
-		reqBody = bytes.NewReader(args)
+	var res *httpclient.Response
+	for attempt := 0; ; attempt++ {
+		reqBody := &bytes.Reader{}
+		if method != "GET" {
+			reqBody = bytes.NewReader(args)
+		}
+
+		res, err = c.client.Do(method, c.absoluteURL(path), c.headers(signature), reqBody)
+		if err == nil || attempt >= c.cfg.Retries || !retryable(method, payload) {
+			break
+		}
+
+		time.Sleep(time.Duration(c.cfg.RetryDelay) * time.Millisecond)
 	}
 
-	res, err := c.client.Do(method, c.absoluteURL(path), c.headers(signature), reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -658,6 +745,17 @@ func (c *Client) doRequest(method, path string, payload map[string]interface{})
 	return c.handleError(res)
 }
 
+// Failed request may still have reached server, so only requests which can be
+// repeated safely are retried: GET and POST identified by proposal ID
+func retryable(method string, payload map[string]interface{}) bool {
+	if method == "GET" {
+		return true
+	}
+
+	_, ok := payload["txProposalId"]
+	return method == "POST" && ok
+}
+
 // Handle errors
 func (c *Client) handleError(res *httpclient.Response) ([]byte, error) {
 	if res.StatusCode == 404 {
@@ -701,6 +799,10 @@ func (c *Client) headers(signature []byte) map[string]string {
 
 func (c *Client) signRequest(method, url, args string) ([]byte, error) {
 	message := strings.Join([]string{strings.ToLower(method), url, args}, "|")
+	return c.signMessage(message)
+}
+
+func (c *Client) signMessage(message string) ([]byte, error) {
 	signature, err := utils.SignMessage([]byte(message), c.keys.ReqPrvKey)
 	if err != nil {
 		return nil, err
@@ -1,9 +1,17 @@
 package client
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -104,11 +112,97 @@ func errorCallback(t *testing.T, expected, res interface{}, err error, msg strin
 	assert.Error(t, err, msg)
 }
 
+// routes maps request paths to handlers of test server, paths are patterns of path.Match
+// which must not overlap, e.g. /v1/txproposals/*/publish/. Empty path handles other requests.
+type routes map[string]http.HandlerFunc
+
+// request is request received by test server
+type request struct {
+	Method  string
+	Path    string
+	Query   url.Values
+	Payload map[string]interface{}
+}
+
+// serverLog records requests received by test server
+type serverLog struct {
+	mutex    sync.Mutex
+	requests []*request
+}
+
+// Requests returns received requests
+func (l *serverLog) Requests() []*request {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]*request{}, l.requests...)
+}
+
+// Calls returns method and path of received requests, e.g. POST /v1/txproposals/
+func (l *serverLog) Calls() []string {
+	calls := []string{}
+	for _, req := range l.Requests() {
+		calls = append(calls, req.Method+" "+req.Path)
+	}
+
+	return calls
+}
+
+// Find returns received requests with method and path of call, e.g. GET /v1/notifications/
+func (l *serverLog) Find(call string) []*request {
+	found := []*request{}
+	for _, req := range l.Requests() {
+		if req.Method+" "+req.Path == call {
+			found = append(found, req)
+		}
+	}
+
+	return found
+}
+
+func (l *serverLog) add(req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	payload := map[string]interface{}{}
+	json.Unmarshal(body, &payload)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.requests = append(l.requests, &request{Method: req.Method, Path: req.URL.Path, Query: req.URL.Query(), Payload: payload})
+}
+
+// respond returns handler answering with status and JSON encoded body
+func respond(status int, body interface{}) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(status)
+		json.NewEncoder(res).Encode(body)
+	}
+}
+
 func newClientServer(t *testing.T, status int, expected interface{}) (*httptest.Server, *Client) {
+	server, client, _ := newRouteServer(t, routes{"": respond(status, expected)})
+	return server, client
+}
+
+// newRouteServer creates test server dispatching requests by routes and client using it
+func newRouteServer(t *testing.T, routes routes) (*httptest.Server, *Client, *serverLog) {
 	// Init handler
+	log := &serverLog{}
 	handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-		res.WriteHeader(status)
-		json.NewEncoder(res).Encode(expected)
+		log.add(req)
+		for pattern, route := range routes {
+			if matched, _ := path.Match(pattern, req.URL.Path); matched && pattern != "" {
+				route(res, req)
+				return
+			}
+		}
+
+		if route, ok := routes[""]; ok {
+			route(res, req)
+			return
+		}
+
+		res.WriteHeader(http.StatusNotFound)
 	})
 
 	// Init server
@@ -132,7 +226,7 @@ func newClientServer(t *testing.T, status int, expected interface{}) (*httptest.
 		assert.FailNow(t, "Error initializing BWS client", err)
 	}
 
-	return server, client
+	return server, client, log
 }
 
 func TestInvalidUrl(t *testing.T) {
@@ -145,6 +239,75 @@ func TestInvalidUrl(t *testing.T) {
 	assert.Error(t, err, "should return error")
 }
 
+func TestRetries(t *testing.T) {
+	var attempts int32
+	handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			conn, _, _ := res.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+
+		json.NewEncoder(res).Encode(&models.Version{ServiceVersion: "bws-2.4.0"})
+	})
+
+	server, client, _ := newRouteServer(t, routes{"": handler})
+	defer server.Close()
+
+	client.cfg.RetryDelay = 1
+
+	response, err := client.GetVersion()
+	assert.Nil(t, response, "should not retry by default")
+	assert.Error(t, err, "should return connection error")
+
+	atomic.StoreInt32(&attempts, 0)
+	client.cfg.Retries = 1
+	response, err = client.GetVersion()
+	assert.NoError(t, err, "should succeed on retry")
+	assert.Equal(t, "bws-2.4.0", response.ServiceVersion, "version should match")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts), "should perform two attempts")
+
+	atomic.StoreInt32(&attempts, 0)
+	_, err = client.doPostRequest("/v1/version/", map[string]interface{}{})
+	assert.Error(t, err, "should not retry POST which may have reached server")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	atomic.StoreInt32(&attempts, 0)
+	_, err = client.doPostRequest("/v1/version/", map[string]interface{}{"txProposalId": "txp"})
+	assert.NoError(t, err, "should retry POST with proposal ID")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestProxy(t *testing.T) {
+	cfg := config.NewPublicTestnet()
+	cfg.Proxy = "127.0.0.1:3128"
+
+	client, err := New(cfg, nil)
+	assert.NoError(t, err, "should create client with proxy")
+	assert.NotNil(t, client, "should create client with proxy")
+
+	// Proxy receives requests with absolute URL
+	authorization := ""
+	proxy := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		authorization = req.Header.Get("Proxy-Authorization")
+		json.NewEncoder(res).Encode(&models.Version{ServiceVersion: "bws-2.4.0"})
+	}))
+	defer proxy.Close()
+
+	cfg.BaseURL = "http://bws.example"
+	cfg.Proxy = strings.Replace(proxy.URL, "http://", "http://user:secret@", 1)
+	client, err = New(cfg, nil)
+	assert.NoError(t, err)
+	_, err = client.client.Get(cfg.BaseURL + "/v1/version/")
+	assert.NoError(t, err, "should request through proxy")
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")), authorization, "should keep proxy credentials")
+
+	cfg.Proxy = "http://%zz"
+	client, err = New(cfg, nil)
+	assert.Nil(t, client, "should not create client with invalid proxy")
+	assert.Error(t, err, "should fail on invalid proxy")
+}
+
 func TestVersion(t *testing.T) {
 	scenarios := []*Scenario{
 		{
@@ -256,6 +419,9 @@ func TestJoinWallet(t *testing.T) {
 
 		response, err := client.JoinWallet("test", secret)
 		scenario.Callback(t, scenario.Expected, response, err, scenario.Message)
+		if scenario.Status != http.StatusOK {
+			assert.Empty(t, client.WalletPubKey(), "should not keep wallet key of failed join")
+		}
 	}
 }
 
@@ -267,6 +433,17 @@ func TestGetStatus(t *testing.T) {
 			Callback: happyCallback,
 			Message:  "wallet should match",
 		},
+		{
+			Status: http.StatusOK,
+			Expected: &models.WalletStatus{
+				Wallet:      mockWallet,
+				Balance:     &models.Balance{TotalAmount: 10000, AvailableAmount: 5000},
+				PendingTxps: []*models.TxProposal{{ID: "txp", Amount: 5000, Status: "pending"}},
+				Preferences: &models.Preferences{Language: "en", Unit: "btc"},
+			},
+			Callback: happyCallback,
+			Message:  "extended status should match",
+		},
 		{
 			Status:   http.StatusOK,
 			Expected: []int{1, 2, 3},
@@ -447,6 +624,25 @@ func TestSavePreferences(t *testing.T) {
 	}
 }
 
+func TestUpdatePreferences(t *testing.T) {
+	server, client, log := newRouteServer(t, routes{"": respond(200, map[string]interface{}{})})
+	defer server.Close()
+
+	err := client.UpdatePreferences(&models.PreferencesUpdate{
+		Unit: pointer.ToString("bit"),
+		Push: []*models.PushSubscription{{Platform: "ios", Token: "device"}},
+	})
+
+	assert.Nil(t, err, "should update preferences")
+	assert.Equal(t, []string{"PUT /v1/preferences/", "POST /v1/pushnotifications/subscriptions/"}, log.Calls(), "should save preferences and subscribe device")
+	assert.Equal(t, map[string]interface{}{"unit": "bit"}, log.Requests()[0].Payload, "should send only fields set")
+	assert.Equal(t, map[string]interface{}{"type": "ios", "token": "device"}, log.Requests()[1].Payload, "should send push subscription")
+
+	err = client.UpdatePreferences(&models.PreferencesUpdate{Unit: pointer.ToString("sat")})
+	assert.Error(t, err, "should validate update")
+	assert.Len(t, log.Requests(), 2, "should not send invalid update")
+}
+
 func TestGetBalance(t *testing.T) {
 	scenarios := []*Scenario{
 		{
@@ -898,10 +1094,10 @@ func TestSignTxProposal(t *testing.T) {
 	}
 
 	for _, scenario := range scenarios {
-		server, client := newClientServer(t, scenario.Status, scenario.Expected)
+		server, client := newVerifiedServer(t, scenario.Status, scenario.Expected)
 		defer server.Close()
 
-		response, err := client.SignTxProposal(mockTxProposal)
+		response, err := client.SignTxProposal(ownProposal(t, client, mockTxProposal))
 		scenario.Callback(t, scenario.Expected, response, err, scenario.Message)
 	}
 }
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/pavel-main/bws-go/models"
+)
+
+// ErrNoCoins is returned when coin control filter matches no spendable coins
+var ErrNoCoins = errors.New("No coins match the filter")
+
+// CoinFilter selects wallet coins (UTXOs) for coin control, zero value matches all spendable coins
+type CoinFilter struct {
+	Outpoints          []string // Only coins with these txid:vout outpoints
+	Addresses          []string // Only coins received on these addresses
+	ExcludeOutpoints   []string // Never coins with these outpoints
+	MinConfirmations   uint     // Only coins with at least this many confirmations
+	ExcludeUnconfirmed bool     // Same as MinConfirmations of 1
+	IncludeLocked      bool     // Also coins locked by pending proposals
+}
+
+// Match reports whether coin passes the filter
+func (f *CoinFilter) Match(input *models.TxInput) bool {
+	if input.Locked && !f.IncludeLocked {
+		return false
+	}
+
+	if input.Confirmations < f.MinConfirmations {
+		return false
+	}
+
+	if f.ExcludeUnconfirmed && input.Confirmations == 0 {
+		return false
+	}
+
+	if len(f.Outpoints) != 0 && !contains(f.Outpoints, input.Outpoint()) {
+		return false
+	}
+
+	if len(f.Addresses) != 0 && !contains(f.Addresses, input.Address) {
+		return false
+	}
+
+	return !contains(f.ExcludeOutpoints, input.Outpoint())
+}
+
+// Filter returns coins passing the filter, preserving their order
+func (f *CoinFilter) Filter(inputs []*models.TxInput) []*models.TxInput {
+	result := []*models.TxInput{}
+	for _, input := range inputs {
+		if f.Match(input) {
+			result = append(result, input)
+		}
+	}
+
+	return result
+}
+
+// SelectCoins returns wallet coins matching the filter, excluding frozen ones
+func (c *Client) SelectCoins(filter CoinFilter) ([]*models.TxInput, error) {
+	utxos, err := c.GetUtxos(filter.Addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	// Frozen coins are never spendable
+	if c.frozen != nil {
+		frozen, err := c.frozen.Outpoints()
+		if err != nil {
+			return nil, err
+		}
+
+		filter.ExcludeOutpoints = append(append([]string{}, filter.ExcludeOutpoints...), frozen...)
+	}
+
+	return filter.Filter(utxos), nil
+}
+
+// CreateTxProposalFromCoins creates proposal spending all coins matching the filter and nothing else,
+// inputs and unconfirmed coins exclusion of opts are replaced by the filter
+func (c *Client) CreateTxProposalFromCoins(filter CoinFilter, opts *TxProposalOptions) (*models.TxProposal, error) {
+	coins, err := c.SelectCoins(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(coins) == 0 {
+		return nil, ErrNoCoins
+	}
+
+	var available, amount int64
+	for _, coin := range coins {
+		available += coin.Satoshis
+	}
+
+	for _, output := range opts.Outputs {
+		if output != nil {
+			amount += output.Amount
+		}
+	}
+
+	if amount > available {
+		return nil, errors.New("Insufficient funds in selected coins")
+	}
+
+	restricted := *opts
+	restricted.Inputs = coins
+	restricted.ExcludeUnconfirmedUtxos = false
+	return c.CreateTxProposalWithOptions(&restricted)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockCoins = []*models.TxInput{
+	{TxID: "aa", Vout: 0, Address: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", Satoshis: 1000, Confirmations: 6},
+	{TxID: "aa", Vout: 1, Address: "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK", Satoshis: 2000, Confirmations: 1},
+	{TxID: "bb", Vout: 0, Address: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", Satoshis: 3000, Confirmations: 0},
+	{TxID: "cc", Vout: 0, Address: "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK", Satoshis: 4000, Confirmations: 10, Locked: true},
+}
+
+func outpoints(inputs []*models.TxInput) []string {
+	result := []string{}
+	for _, input := range inputs {
+		result = append(result, input.Outpoint())
+	}
+
+	return result
+}
+
+func TestCoinFilter(t *testing.T) {
+	scenarios := []struct {
+		Filter   CoinFilter
+		Expected []string
+		Message  string
+	}{
+		{CoinFilter{}, []string{"aa:0", "aa:1", "bb:0"}, "should exclude locked coins by default"},
+		{CoinFilter{IncludeLocked: true}, []string{"aa:0", "aa:1", "bb:0", "cc:0"}, "should include locked coins"},
+		{CoinFilter{ExcludeUnconfirmed: true}, []string{"aa:0", "aa:1"}, "should exclude unconfirmed coins"},
+		{CoinFilter{MinConfirmations: 6}, []string{"aa:0"}, "should filter by confirmations"},
+		{CoinFilter{Outpoints: []string{"aa:1", "cc:0"}}, []string{"aa:1"}, "should filter by outpoint"},
+		{CoinFilter{Addresses: []string{"mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"}}, []string{"aa:0", "bb:0"}, "should filter by address"},
+		{CoinFilter{ExcludeOutpoints: []string{"aa:0"}}, []string{"aa:1", "bb:0"}, "should exclude outpoints"},
+	}
+
+	for _, scenario := range scenarios {
+		assert.Equal(t, scenario.Expected, outpoints(scenario.Filter.Filter(mockCoins)), scenario.Message)
+	}
+}
+
+func TestCreateTxProposalFromCoins(t *testing.T) {
+	server, client, log := newRouteServer(t, routes{
+		"/v1/utxos/": respond(200, mockCoins),
+		"":           respond(200, &models.TxProposal{ID: "txp"}),
+	})
+	defer server.Close()
+
+	opts := &TxProposalOptions{Outputs: models.NewTxOutputSingle(2500, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"), ExcludeUnconfirmedUtxos: true}
+	txp, err := client.CreateTxProposalFromCoins(CoinFilter{ExcludeUnconfirmed: true}, opts)
+	assert.NoError(t, err, "should create proposal")
+	assert.Equal(t, "txp", txp.ID)
+
+	payload := log.Requests()[1].Payload
+	inputs := payload["inputs"].([]interface{})
+	assert.Len(t, inputs, 2, "should spend only selected coins")
+	assert.Equal(t, "aa", inputs[0].(map[string]interface{})["txid"])
+	assert.NotContains(t, payload, "excludeUnconfirmedUtxos", "should not conflict with explicit inputs")
+
+	_, err = client.CreateTxProposalFromCoins(CoinFilter{Outpoints: []string{"dd:0"}}, opts)
+	assert.Equal(t, ErrNoCoins, err, "should fail without matching coins")
+
+	_, err = client.CreateTxProposalFromCoins(CoinFilter{MinConfirmations: 6}, opts)
+	assert.Error(t, err, "should fail when selected coins are not enough")
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pavel-main/bws-go/fees"
+	"github.com/pavel-main/bws-go/models"
+)
+
+// ErrNoParentOutputs is returned when wallet has no spendable outputs of transaction to accelerate
+var ErrNoParentOutputs = errors.New("Wallet has no spendable outputs of transaction")
+
+// CPFPOptions configures child-pays-for-parent acceleration
+type CPFPOptions struct {
+	FeeLevel   string          // Target fee level of parent and child package, defaults to normal unless FeePerKb is set
+	FeePerKb   uint64          // Custom target fee rate in satoshis per kilobyte
+	ParentSize int             // Parent virtual size, derived from its fees and fee rate when not set
+	Sizes      *fees.Estimator // Wallet size model, loaded from wallet status if not set
+	DryRun     bool            // Only estimate proposal without storing it
+	Publish    bool            // Publish created proposal, ignored for dry runs
+}
+
+// CPFPPlan describes child transaction lifting parent to target fee rate
+type CPFPPlan struct {
+	Inputs     []*models.TxInput `json:"inputs"`     // Wallet outputs of parent spent by child
+	ParentSize int               `json:"parentSize"` // Parent virtual size
+	ParentFee  int64             `json:"parentFee"`  // Fee paid by parent
+	ChildSize  int               `json:"childSize"`  // Estimated child virtual size
+	FeePerKb   uint64            `json:"feePerKb"`   // Target package fee rate
+	Fee        int64             `json:"fee"`        // Child fee
+	Amount     int64             `json:"amount"`     // Amount returned to wallet
+}
+
+// PlanCPFP finds wallet outputs of unconfirmed transaction and computes child fee
+// making the package of both transactions pay target fee rate
+func (c *Client) PlanCPFP(tx *models.Transaction, opts CPFPOptions) (*CPFPPlan, error) {
+	if tx.Confirmations > 0 {
+		return nil, errors.New("Transaction is already confirmed")
+	}
+
+	parentSize := opts.ParentSize
+	if parentSize == 0 {
+		if tx.FeePerKB == 0 {
+			return nil, errors.New("Transaction size is unknown")
+		}
+
+		parentSize = int(tx.Fees * 1000 / int64(tx.FeePerKB))
+	}
+
+	feePerKb := opts.FeePerKb
+	if feePerKb == 0 {
+		rate, err := c.FeePerKb(opts.FeeLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		feePerKb = rate
+	}
+
+	if tx.Fees >= fees.Fee(parentSize, feePerKb) {
+		return nil, fmt.Errorf("Transaction already pays fee rate %d", feePerKb)
+	}
+
+	coins, err := c.SelectCoins(CoinFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := []*models.TxInput{}
+	var total int64
+	for _, coin := range coins {
+		if coin.TxID == tx.TxID {
+			inputs = append(inputs, coin)
+			total += coin.Satoshis
+		}
+	}
+
+	if len(inputs) == 0 {
+		return nil, ErrNoParentOutputs
+	}
+
+	sizes := opts.Sizes
+	if sizes == nil {
+		if sizes, err = c.Estimator(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Child pays for the whole package, but never less than for itself
+	childSize := sizes.VSize(len(inputs), 1)
+	fee := fees.Fee(parentSize+childSize, feePerKb) - tx.Fees
+	if own := fees.Fee(childSize, feePerKb); fee < own {
+		fee = own
+	}
+
+	amount := total - fee
+	if amount < dustLimit {
+		return nil, fmt.Errorf("Outputs worth %d cannot pay child fee %d", total, fee)
+	}
+
+	return &CPFPPlan{
+		Inputs:     inputs,
+		ParentSize: parentSize,
+		ParentFee:  tx.Fees,
+		ChildSize:  childSize,
+		FeePerKb:   feePerKb,
+		Fee:        fee,
+		Amount:     amount,
+	}, nil
+}
+
+// CPFP creates proposal spending wallet outputs of low fee transaction back to new wallet address,
+// paying fee that lifts the package to target fee rate
+func (c *Client) CPFP(tx *models.Transaction, opts CPFPOptions) (*models.TxProposal, *CPFPPlan, error) {
+	plan, err := c.PlanCPFP(tx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Dry run must not derive new address on server, address of parent output stands in for it
+	address := plan.Inputs[0].Address
+	if !opts.DryRun {
+		created, err := c.CreateAddress(false)
+		if err != nil {
+			return nil, plan, err
+		}
+
+		address = created.Address
+	}
+
+	txp, err := c.CreateTxProposalWithOptions(&TxProposalOptions{
+		Outputs:          models.NewTxOutputSingle(plan.Amount, address),
+		Inputs:           plan.Inputs,
+		Fee:              plan.Fee,
+		NoShuffleOutputs: true,
+		DryRun:           opts.DryRun,
+	})
+
+	if err != nil {
+		return nil, plan, err
+	}
+
+	if txp.Amount != plan.Amount || txp.Fee != plan.Fee || (len(txp.Inputs) != 0 && !sameInputs(txp.Inputs, plan.Inputs)) {
+		return nil, plan, c.discardTxProposal(txp, opts.DryRun, errors.New("Proposal does not match child transaction plan"))
+	}
+
+	if opts.Publish && !opts.DryRun {
+		if txp, err = c.PublishTxProposal(txp); err != nil {
+			return nil, plan, err
+		}
+	}
+
+	return txp, plan, nil
+}
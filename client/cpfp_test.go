@@ -0,0 +1,75 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pavel-main/bws-go/fees"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCPFP(t *testing.T) {
+	utxos := []*models.TxInput{
+		{TxID: "parent", Vout: 1, Satoshis: 50000, Address: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"},
+		{TxID: "other", Vout: 0, Satoshis: 90000, Confirmations: 3},
+	}
+
+	var payload map[string]interface{}
+	amount := int64(45830)
+	server, client, log := newRouteServer(t, routes{
+		"/v1/utxos/":     respond(200, utxos),
+		"/v2/feelevels/": respond(200, []*models.FeeLevel{{Level: "normal", FeePerKb: 10000}}),
+		"/v3/addresses/": respond(200, &models.Address{Address: "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK"}),
+		"": func(res http.ResponseWriter, req *http.Request) {
+			payload = map[string]interface{}{}
+			json.NewDecoder(req.Body).Decode(&payload)
+			fee, _ := payload["fee"].(float64)
+			json.NewEncoder(res).Encode(&models.TxProposal{
+				ID:     "child",
+				Amount: amount,
+				Fee:    int64(fee),
+				Inputs: utxos[:1],
+			})
+		},
+	})
+	defer server.Close()
+
+	sizes, _ := fees.New(1, 1, fees.AddressP2PKH)
+	tx := &models.Transaction{TxID: "parent", Fees: 250, FeePerKB: 1000, LowFees: true}
+	txp, plan, err := client.CPFP(tx, CPFPOptions{Sizes: sizes, DryRun: true})
+	assert.NoError(t, err, "should create child proposal")
+	assert.Equal(t, "child", txp.ID)
+	assert.Equal(t, []string{"parent:1"}, outpoints(plan.Inputs), "should spend only parent outputs")
+	assert.Equal(t, 250, plan.ParentSize, "should derive parent size from fee rate")
+	assert.Equal(t, 192, plan.ChildSize)
+	assert.Equal(t, int64(4170), plan.Fee, "should lift package of 442 vbytes to 10 sat/vbyte")
+	assert.Equal(t, int64(45830), plan.Amount)
+	assert.Equal(t, float64(4170), payload["fee"], "should pass child fee")
+	assert.Equal(t, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", payload["outputs"].([]interface{})[0].(map[string]interface{})["toAddress"], "should send back to wallet")
+	assert.NotContains(t, log.Calls(), "POST /v3/addresses/", "should not create address in dry run")
+
+	_, _, err = client.CPFP(tx, CPFPOptions{Sizes: sizes})
+	assert.NoError(t, err, "should create child proposal")
+	assert.Equal(t, "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK", payload["outputs"].([]interface{})[0].(map[string]interface{})["toAddress"], "should send to new address")
+
+	amount--
+	_, _, err = client.CPFP(tx, CPFPOptions{Sizes: sizes})
+	assert.Error(t, err, "should reject proposal not matching plan")
+	assert.Equal(t, "DELETE /v1/txproposals/child", log.Calls()[len(log.Calls())-1], "should remove mismatching proposal")
+	amount++
+
+	// Parent already paying target rate
+	_, _, err = client.CPFP(&models.Transaction{TxID: "parent", Fees: 2500, FeePerKB: 10000}, CPFPOptions{Sizes: sizes})
+	assert.Error(t, err, "should not accelerate transaction paying target rate")
+
+	_, _, err = client.CPFP(&models.Transaction{TxID: "unknown", Fees: 250, FeePerKB: 1000}, CPFPOptions{Sizes: sizes})
+	assert.Equal(t, ErrNoParentOutputs, err, "should require wallet outputs of parent")
+
+	_, _, err = client.CPFP(&models.Transaction{TxID: "parent", Fees: 250, FeePerKB: 1000}, CPFPOptions{Sizes: sizes, FeePerKb: 200000})
+	assert.Error(t, err, "should fail when outputs cannot pay child fee")
+
+	_, _, err = client.CPFP(&models.Transaction{TxID: "parent", Confirmations: 1}, CPFPOptions{Sizes: sizes})
+	assert.Error(t, err, "should not accelerate confirmed transaction")
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// FinalizeTxProposal assembles fully signed transaction from proposal signatures locally
+// and returns it hex-encoded, ready for BroadcastRawTx
+func (c *Client) FinalizeTxProposal(txp *models.TxProposal) (string, error) {
+	tx, err := c.finalize(txp)
+	if err != nil {
+		return "", err
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := tx.Serialize(buffer); err != nil {
+		return "", err
+	}
+
+	return utils.ToHex(buffer.Bytes()), nil
+}
+
+// CheckBroadcast fetches accepted proposal, assembles its signed transaction locally,
+// executes every input with script engine and ensures txid matches the one reported by server
+func (c *Client) CheckBroadcast(txpID string) (*models.TxProposal, error) {
+	txp, err := c.GetTx(txpID)
+	if err != nil {
+		return nil, err
+	}
+
+	if txp.TxID == "" {
+		return nil, errors.New("Proposal has no transaction ID")
+	}
+
+	tx, err := c.finalize(txp)
+	if err != nil {
+		return nil, err
+	}
+
+	if txID := tx.TxHash().String(); txID != txp.TxID {
+		return nil, fmt.Errorf("Transaction ID %s does not match proposal transaction %s", txID, txp.TxID)
+	}
+
+	return txp, nil
+}
+
+// BroadcastTxProposalChecked broadcasts proposal only if CheckBroadcast passes
+func (c *Client) BroadcastTxProposalChecked(txpID string) (*models.TxProposal, error) {
+	if _, err := c.CheckBroadcast(txpID); err != nil {
+		return nil, err
+	}
+
+	return c.BroadcastTxProposal(txpID)
+}
+
+func (c *Client) finalize(txp *models.TxProposal) (*wire.MsgTx, error) {
+	if txp.Status != statusAccepted && txp.Status != statusBroadcasted {
+		return nil, errors.New("Proposal is not accepted by enough copayers")
+	}
+
+	return txp.Finalize(c.cfg.NetParams())
+}
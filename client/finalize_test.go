@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinalizeTxProposal(t *testing.T) {
+	_, client := newClientServer(t, 200, nil)
+
+	txp := mockReplaceable()
+	txp.Status = "pending"
+	_, err := client.FinalizeTxProposal(txp)
+	assert.Error(t, err, "should require accepted proposal")
+
+	txp.Status = "accepted"
+	_, err = client.FinalizeTxProposal(txp)
+	assert.Error(t, err, "should require signatures")
+
+	signOwnCoin(t, client, txp)
+	rawTx, err := client.FinalizeTxProposal(txp)
+	assert.NoError(t, err, "should finalize proposal")
+
+	unsigned, _ := txp.Serialize(client.cfg.NetParams())
+	assert.True(t, len(rawTx) > 2*len(unsigned), "should include script signature")
+}
+
+// signOwnCoin makes proposal input spend coin of client signed by client
+func signOwnCoin(t *testing.T, client *Client, txp *models.TxProposal) {
+	privKey, pubKey, _ := client.keys.DeriveFromAccount(txp.Inputs[0].Path)
+	address, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), client.cfg.NetParams())
+	script, _ := txscript.PayToAddrScript(address)
+	txp.Inputs[0].ScriptPubKey = utils.ToHex(script)
+
+	signature, err := txp.InputSignature(privKey, client.cfg.NetParams(), 0)
+	assert.NoError(t, err, "should sign input")
+	txp.Actions = []*models.TxAction{{
+		Type:       models.ActionAccept,
+		XPub:       client.keys.AccExtPubKey.String(),
+		Signatures: []string{utils.ToHex(signature)},
+	}}
+}
+
+func TestBroadcastTxProposalChecked(t *testing.T) {
+	var txp *models.TxProposal
+	server, client, log := newRouteServer(t, routes{"": func(res http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(res).Encode(txp)
+	}})
+	defer server.Close()
+
+	txp = mockReplaceable()
+	txp.Status = "accepted"
+	signOwnCoin(t, client, txp)
+	tx, _ := txp.Finalize(client.cfg.NetParams())
+	txp.TxID = tx.TxHash().String()
+
+	_, err := client.BroadcastTxProposalChecked(txp.ID)
+	assert.NoError(t, err, "should broadcast verified proposal")
+	assert.Equal(t, []string{"GET /v1/txproposals/original", "POST /v1/txproposals/original/broadcast/"}, log.Calls())
+
+	broadcast := len(log.Calls())
+	txp.TxID = "f00d"
+	_, err = client.BroadcastTxProposalChecked(txp.ID)
+	assert.Error(t, err, "should detect txid mismatch")
+
+	txp.TxID = tx.TxHash().String()
+	txp.Actions[0].Signatures[0] = txp.Actions[0].Signatures[0][:len(txp.Actions[0].Signatures[0])-2] + "00"
+	_, err = client.BroadcastTxProposalChecked(txp.ID)
+	assert.IsType(t, &models.VerificationError{}, err, "should report failed inputs")
+
+	txp.TxID = ""
+	_, err = client.BroadcastTxProposalChecked(txp.ID)
+	assert.Error(t, err, "should require transaction ID")
+	assert.Equal(t, []string{"GET /v1/txproposals/original", "GET /v1/txproposals/original", "GET /v1/txproposals/original"}, log.Calls()[broadcast:], "should never broadcast")
+}
@@ -0,0 +1,325 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pavel-main/bws-go/models"
+)
+
+// FrozenCoin represents coin that must never be spent
+type FrozenCoin struct {
+	Outpoint string    `json:"outpoint"` // txid:vout
+	Note     string    `json:"note"`
+	FrozenAt time.Time `json:"frozenAt"`
+}
+
+// FrozenCoinError is returned when proposal spends frozen coins
+type FrozenCoinError struct {
+	Outpoints []string
+}
+
+func (e *FrozenCoinError) Error() string {
+	return fmt.Sprintf("Proposal spends frozen coins: %s", strings.Join(e.Outpoints, ", "))
+}
+
+// FreezeStore persists frozen coins
+type FreezeStore interface {
+	Load() ([]*FrozenCoin, error)
+	Save(coins []*FrozenCoin) error
+}
+
+// MemoryFreezeStore keeps frozen coins in memory, useful for tests and short-lived processes
+type MemoryFreezeStore struct {
+	mutex sync.Mutex
+	coins []*FrozenCoin
+}
+
+// Load returns stored coins
+func (s *MemoryFreezeStore) Load() ([]*FrozenCoin, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*FrozenCoin{}, s.coins...), nil
+}
+
+// Save stores coins
+func (s *MemoryFreezeStore) Save(coins []*FrozenCoin) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.coins = append([]*FrozenCoin{}, coins...)
+	return nil
+}
+
+// FileFreezeStore keeps frozen coins in JSON file, missing file means empty list
+type FileFreezeStore struct {
+	Path string
+}
+
+// Load reads coins from file
+func (s *FileFreezeStore) Load() ([]*FrozenCoin, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return []*FrozenCoin{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	coins := []*FrozenCoin{}
+	if err := json.Unmarshal(data, &coins); err != nil {
+		return nil, err
+	}
+
+	return coins, nil
+}
+
+// Save atomically replaces file
+func (s *FileFreezeStore) Save(coins []*FrozenCoin) error {
+	data, err := json.MarshalIndent(coins, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.Path, data)
+}
+
+// writeFileAtomic writes data to temporary file and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// FreezeList manages frozen coins kept in FreezeStore
+type FreezeList struct {
+	mutex sync.Mutex
+	store FreezeStore
+}
+
+// NewFreezeList creates freeze list backed by store, defaults to MemoryFreezeStore
+func NewFreezeList(store FreezeStore) *FreezeList {
+	if store == nil {
+		store = &MemoryFreezeStore{}
+	}
+
+	return &FreezeList{store: store}
+}
+
+// normalizeOutpoint validates outpoint and returns it in txid:vout form used by BWS
+func normalizeOutpoint(outpoint string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(outpoint), ":")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("Invalid outpoint %s, expected txid:vout", outpoint)
+	}
+
+	txid := strings.ToLower(parts[0])
+	if decoded, err := hex.DecodeString(txid); err != nil || len(decoded) != 32 {
+		return "", fmt.Errorf("Invalid outpoint %s, transaction ID must be 64 hex characters", outpoint)
+	}
+
+	vout, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("Invalid outpoint %s, output index must be a number", outpoint)
+	}
+
+	return fmt.Sprintf("%s:%d", txid, vout), nil
+}
+
+// Freeze adds coin to the list, freezing already frozen coin replaces its note
+func (l *FreezeList) Freeze(outpoint, note string) error {
+	outpoint, err := normalizeOutpoint(outpoint)
+	if err != nil {
+		return err
+	}
+
+	return l.update(func(coins map[string]*FrozenCoin) error {
+		if coin, ok := coins[outpoint]; ok {
+			coin.Note = note
+			return nil
+		}
+
+		coins[outpoint] = &FrozenCoin{Outpoint: outpoint, Note: note, FrozenAt: time.Now().UTC()}
+		return nil
+	})
+}
+
+// Annotate replaces note of frozen coin
+func (l *FreezeList) Annotate(outpoint, note string) error {
+	outpoint, err := normalizeOutpoint(outpoint)
+	if err != nil {
+		return err
+	}
+
+	return l.update(func(coins map[string]*FrozenCoin) error {
+		coin, ok := coins[outpoint]
+		if !ok {
+			return fmt.Errorf("Coin %s is not frozen", outpoint)
+		}
+
+		coin.Note = note
+		return nil
+	})
+}
+
+// Unfreeze removes coin from the list
+func (l *FreezeList) Unfreeze(outpoint string) error {
+	outpoint, err := normalizeOutpoint(outpoint)
+	if err != nil {
+		return err
+	}
+
+	return l.update(func(coins map[string]*FrozenCoin) error {
+		delete(coins, outpoint)
+		return nil
+	})
+}
+
+// Clear removes all coins from the list
+func (l *FreezeList) Clear() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.store.Save([]*FrozenCoin{})
+}
+
+// List returns frozen coins ordered by outpoint
+func (l *FreezeList) List() ([]*FrozenCoin, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	coins, err := l.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(coins, func(i, j int) bool { return coins[i].Outpoint < coins[j].Outpoint })
+	return coins, nil
+}
+
+// Check returns FrozenCoinError if any of inputs is frozen
+func (l *FreezeList) Check(inputs []*models.TxInput) error {
+	coins, err := l.List()
+	if err != nil {
+		return err
+	}
+
+	frozen := map[string]bool{}
+	for _, coin := range coins {
+		frozen[coin.Outpoint] = true
+	}
+
+	outpoints := []string{}
+	for _, input := range inputs {
+		if frozen[input.Outpoint()] {
+			outpoints = append(outpoints, input.Outpoint())
+		}
+	}
+
+	if len(outpoints) != 0 {
+		return &FrozenCoinError{Outpoints: outpoints}
+	}
+
+	return nil
+}
+
+// Outpoints returns outpoints of frozen coins
+func (l *FreezeList) Outpoints() ([]string, error) {
+	coins, err := l.List()
+	if err != nil {
+		return nil, err
+	}
+
+	outpoints := []string{}
+	for _, coin := range coins {
+		outpoints = append(outpoints, coin.Outpoint)
+	}
+
+	return outpoints, nil
+}
+
+func (l *FreezeList) update(change func(coins map[string]*FrozenCoin) error) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	list, err := l.store.Load()
+	if err != nil {
+		return err
+	}
+
+	coins := map[string]*FrozenCoin{}
+	for _, coin := range list {
+		coins[coin.Outpoint] = coin
+	}
+
+	if err := change(coins); err != nil {
+		return err
+	}
+
+	list = []*FrozenCoin{}
+	for _, coin := range coins {
+		list = append(list, coin)
+	}
+
+	return l.store.Save(list)
+}
+
+// SetFreezeList makes client refuse creating and signing proposals spending frozen coins
+func (c *Client) SetFreezeList(list *FreezeList) {
+	c.frozen = list
+}
+
+// checkFrozen fails if inputs spend frozen coins
+func (c *Client) checkFrozen(inputs []*models.TxInput) error {
+	if c.frozen == nil {
+		return nil
+	}
+
+	return c.frozen.Check(inputs)
+}
+
+// frozenOutpoints returns outpoints BWS must exclude from coin selection
+func (c *Client) frozenOutpoints() ([]string, error) {
+	if c.frozen == nil {
+		return nil, nil
+	}
+
+	return c.frozen.Outpoints()
+}
+
+// checkFrozenProposal removes proposal created by BWS with frozen coins selected,
+// frozen coins are excluded in request so this only guards against server ignoring it
+func (c *Client) checkFrozenProposal(txp *models.TxProposal, dryRun bool) error {
+	err := c.checkFrozen(txp.Inputs)
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*FrozenCoinError); ok {
+		return c.discardTxProposal(txp, dryRun, err)
+	}
+
+	return err
+}
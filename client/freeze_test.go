@@ -0,0 +1,115 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	txidA = strings.Repeat("aa", 32)
+	txidB = strings.Repeat("bb", 32)
+)
+
+var mockFreezeCoins = []*models.TxInput{
+	{TxID: txidA, Vout: 0, Address: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", Satoshis: 1000, Confirmations: 6},
+	{TxID: txidA, Vout: 1, Address: "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK", Satoshis: 2000, Confirmations: 1},
+	{TxID: txidB, Vout: 0, Address: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", Satoshis: 3000, Confirmations: 0},
+}
+
+func TestFreezeList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bws-freeze")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "frozen.json")
+	list := NewFreezeList(&FileFreezeStore{Path: path})
+
+	for _, outpoint := range []string{"", "aa:0", txidA, txidA + ":x", txidA + ":-1", txidA + ":4294967296", txidA + ":0:1"} {
+		assert.Error(t, list.Freeze(outpoint, ""), "should reject invalid outpoint %s", outpoint)
+	}
+
+	assert.NoError(t, list.Freeze(txidB+":0", "dispute"), "should freeze coin")
+	assert.NoError(t, list.Freeze(strings.ToUpper(txidA)+":01", "legal hold"), "should normalize outpoint")
+	assert.NoError(t, list.Annotate(txidB+":0", "dispute #42"), "should annotate frozen coin")
+	assert.Error(t, list.Annotate(strings.Repeat("cc", 32)+":0", "unknown"), "should not annotate coin that is not frozen")
+
+	// Reopen from disk
+	list = NewFreezeList(&FileFreezeStore{Path: path})
+	coins, err := list.List()
+	assert.NoError(t, err, "should list frozen coins")
+	assert.Len(t, coins, 2)
+	assert.Equal(t, txidA+":1", coins[0].Outpoint, "should order by outpoint")
+	assert.Equal(t, "dispute #42", coins[1].Note, "should persist notes")
+	assert.False(t, coins[0].FrozenAt.IsZero(), "should record freeze time")
+
+	err = list.Check(mockFreezeCoins)
+	assert.Equal(t, &FrozenCoinError{Outpoints: []string{txidA + ":1", txidB + ":0"}}, err, "should report frozen inputs")
+
+	assert.NoError(t, list.Unfreeze(txidA+":1"), "should unfreeze coin")
+	assert.Equal(t, &FrozenCoinError{Outpoints: []string{txidB + ":0"}}, list.Check(mockFreezeCoins))
+
+	assert.NoError(t, list.Clear(), "should clear list")
+	assert.NoError(t, list.Check(mockFreezeCoins), "should not report cleared coins")
+}
+
+func TestFreezeListClient(t *testing.T) {
+	txp := &models.TxProposal{ID: "txp", Inputs: []*models.TxInput{mockFreezeCoins[1]}}
+	server, client, log := newRouteServer(t, routes{
+		"/v1/utxos/": respond(200, mockFreezeCoins),
+		"":           respond(200, txp),
+	})
+	defer server.Close()
+
+	list := NewFreezeList(nil)
+	list.Freeze(txidA+":1", "legal hold")
+	client.SetFreezeList(list)
+
+	// Frozen coins are not selected
+	coins, err := client.SelectCoins(CoinFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{txidA + ":0", txidB + ":0"}, outpoints(coins), "should skip frozen coins")
+
+	// Explicit frozen inputs are rejected before request
+	outputs := models.NewTxOutputSingle(1000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY")
+	_, err = client.CreateTxProposalWithOptions(&TxProposalOptions{Outputs: outputs, Inputs: []*models.TxInput{mockFreezeCoins[1]}})
+	assert.IsType(t, &FrozenCoinError{}, err, "should reject frozen inputs")
+	assert.Len(t, log.Requests(), 1, "should not send proposal")
+
+	// Proposal with frozen coins selected by BWS is removed
+	_, err = client.CreateTxProposalWithOptions(&TxProposalOptions{Outputs: outputs})
+	assert.IsType(t, &FrozenCoinError{}, err, "should reject frozen coins selected by server")
+	assert.Equal(t, []string{"POST /v2/txproposals/", "DELETE /v1/txproposals/txp"}, log.Calls()[1:], "should remove temporary proposal")
+	assert.Equal(t, []interface{}{txidA + ":1"}, log.Requests()[1].Payload["utxosToExclude"], "should ask server to exclude frozen coins")
+
+	// Signing is refused
+	_, err = client.SignTxProposal(txp)
+	assert.IsType(t, &FrozenCoinError{}, err, "should refuse to sign frozen coins")
+	assert.Len(t, log.Requests(), 3, "should not send signatures")
+
+	// Send max info excludes frozen coins
+	client.GetMaxInfo("", 0)
+	assert.Equal(t, txidA+":1", log.Requests()[3].Query.Get("utxosToExclude"), "should exclude frozen coins from send max info")
+}
+
+func TestFreezeListRemoveFailure(t *testing.T) {
+	txp := &models.TxProposal{ID: "txp", Inputs: []*models.TxInput{mockFreezeCoins[1]}}
+	server, client, _ := newRouteServer(t, routes{
+		"/v2/txproposals/":    respond(200, txp),
+		"/v1/txproposals/txp": respond(500, map[string]string{"code": "ERROR", "message": "unavailable"}),
+	})
+	defer server.Close()
+
+	list := NewFreezeList(nil)
+	list.Freeze(txidA+":1", "legal hold")
+	client.SetFreezeList(list)
+
+	_, err := client.CreateTxProposal(models.NewTxOutputSingle(1000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"), "", false)
+	assert.Error(t, err, "should fail on frozen coins")
+	assert.Contains(t, err.Error(), "removing proposal txp failed", "should report proposal left on server")
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pavel-main/bws-go/models"
+)
+
+const defaultHistoryPageSize = 50
+
+// HistoryOptions configures transaction history iteration
+type HistoryOptions struct {
+	PageSize            uint64    // Number of transactions per request, defaults to 50
+	IncludeExtendedInfo bool      // Passed to every GetTxHistory request
+	StopAtTxID          string    // Iteration stops before this transaction
+	StopBefore          time.Time // Iteration stops at first transaction older than this time
+	Prefetch            int       // Number of pages fetched ahead in background, 0 fetches on demand
+}
+
+// HistoryIterator walks the whole transaction history page by page,
+// skipping transactions already seen when new ones shift page boundaries
+type HistoryIterator struct {
+	client  *Client
+	opts    HistoryOptions
+	seen    map[string]bool
+	buffer  []*models.Transaction
+	current *models.Transaction
+	err     error
+
+	// On-demand fetching
+	skip uint64
+	last bool
+
+	// Background fetching
+	pages    chan *historyPage
+	done     chan struct{}
+	closed   sync.Once
+	finished bool
+}
+
+type historyPage struct {
+	txs []*models.Transaction
+	err error
+}
+
+// TxHistory returns iterator over the whole transaction history, newest first
+func (c *Client) TxHistory(opts HistoryOptions) *HistoryIterator {
+	if opts.PageSize == 0 {
+		opts.PageSize = defaultHistoryPageSize
+	}
+
+	it := &HistoryIterator{
+		client: c,
+		opts:   opts,
+		seen:   map[string]bool{},
+		done:   make(chan struct{}),
+	}
+
+	if opts.Prefetch > 0 {
+		it.pages = make(chan *historyPage, opts.Prefetch)
+		go it.prefetch()
+	}
+
+	return it
+}
+
+// Next advances iterator to the next unique transaction, returns false when done or failed
+func (it *HistoryIterator) Next() bool {
+	for !it.finished {
+		if len(it.buffer) == 0 {
+			page, ok := it.nextPage()
+			if !ok {
+				break
+			}
+
+			if page.err != nil {
+				it.err = page.err
+				break
+			}
+
+			it.buffer = page.txs
+			continue
+		}
+
+		tx := it.buffer[0]
+		it.buffer = it.buffer[1:]
+
+		// Page boundaries shifted, transaction was already returned
+		if it.seen[tx.TxID] {
+			continue
+		}
+
+		it.seen[tx.TxID] = true
+		if it.stopAt(tx) {
+			break
+		}
+
+		it.current = tx
+		return true
+	}
+
+	it.current = nil
+	it.Close()
+	return false
+}
+
+// Tx returns current transaction
+func (it *HistoryIterator) Tx() *models.Transaction {
+	return it.current
+}
+
+// Err returns error occurred during iteration
+func (it *HistoryIterator) Err() error {
+	return it.err
+}
+
+// Close stops iteration and background fetching
+func (it *HistoryIterator) Close() {
+	it.finished = true
+	it.closed.Do(func() {
+		close(it.done)
+	})
+}
+
+// All collects remaining transactions
+func (it *HistoryIterator) All() ([]*models.Transaction, error) {
+	result := []*models.Transaction{}
+	for it.Next() {
+		result = append(result, it.Tx())
+	}
+
+	if it.err != nil {
+		return nil, it.err
+	}
+
+	return result, nil
+}
+
+func (it *HistoryIterator) stopAt(tx *models.Transaction) bool {
+	if it.opts.StopAtTxID != "" && tx.TxID == it.opts.StopAtTxID {
+		return true
+	}
+
+	if !it.opts.StopBefore.IsZero() && int64(tx.Time) < it.opts.StopBefore.Unix() {
+		return true
+	}
+
+	return false
+}
+
+func (it *HistoryIterator) nextPage() (*historyPage, bool) {
+	if it.pages != nil {
+		page, ok := <-it.pages
+		return page, ok
+	}
+
+	if it.last {
+		return nil, false
+	}
+
+	page := it.fetch(it.skip)
+	it.skip += it.opts.PageSize
+	it.last = it.isLast(page)
+	return page, true
+}
+
+func (it *HistoryIterator) prefetch() {
+	defer close(it.pages)
+
+	for skip := uint64(0); ; skip += it.opts.PageSize {
+		page := it.fetch(skip)
+		select {
+		case it.pages <- page:
+		case <-it.done:
+			return
+		}
+
+		if it.isLast(page) {
+			return
+		}
+	}
+}
+
+func (it *HistoryIterator) fetch(skip uint64) *historyPage {
+	txs, err := it.client.GetTxHistory(skip, it.opts.PageSize, it.opts.IncludeExtendedInfo)
+	return &historyPage{txs: txs, err: err}
+}
+
+func (it *HistoryIterator) isLast(page *historyPage) bool {
+	return page.err != nil || uint64(len(page.txs)) < it.opts.PageSize
+}
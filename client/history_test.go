@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// historyRoute serves paginated history, calling onPage after every request
+func historyRoute(history []*models.Transaction, onPage func(skip int) []*models.Transaction) http.HandlerFunc {
+	var mutex sync.Mutex
+	return func(res http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		skip, _ := strconv.Atoi(req.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+
+		page := []*models.Transaction{}
+		for i := skip; i < skip+limit && i < len(history); i++ {
+			page = append(page, history[i])
+		}
+
+		if onPage != nil {
+			history = onPage(skip)
+		}
+
+		json.NewEncoder(res).Encode(page)
+	}
+}
+
+func mockHistory(count int) []*models.Transaction {
+	history := []*models.Transaction{}
+	for i := 0; i < count; i++ {
+		history = append(history, &models.Transaction{
+			TxID: fmt.Sprintf("tx%d", i),
+			Time: uint(1538558571 - i*60),
+		})
+	}
+
+	return history
+}
+
+func txIDs(txs []*models.Transaction) []string {
+	result := []string{}
+	for _, tx := range txs {
+		result = append(result, tx.TxID)
+	}
+
+	return result
+}
+
+func TestTxHistory(t *testing.T) {
+	history := mockHistory(7)
+	server, client, _ := newRouteServer(t, routes{"/v1/txhistory/": historyRoute(history, nil)})
+	defer server.Close()
+
+	for _, prefetch := range []int{0, 2} {
+		txs, err := client.TxHistory(HistoryOptions{PageSize: 3, Prefetch: prefetch}).All()
+		assert.NoError(t, err, "should walk whole history")
+		assert.Equal(t, txIDs(history), txIDs(txs), "should return all transactions in order")
+	}
+}
+
+func TestTxHistoryShift(t *testing.T) {
+	history := mockHistory(5)
+	shifted := false
+	onPage := func(skip int) []*models.Transaction {
+		if shifted {
+			return history
+		}
+
+		// Two new transactions arrive after the first page
+		shifted = true
+		history = append([]*models.Transaction{{TxID: "new1"}, {TxID: "new2"}}, history...)
+		return history
+	}
+
+	server, client, _ := newRouteServer(t, routes{"/v1/txhistory/": historyRoute(history, onPage)})
+	defer server.Close()
+
+	txs, err := client.TxHistory(HistoryOptions{PageSize: 2}).All()
+	assert.NoError(t, err, "should walk whole history")
+	assert.Equal(t, []string{"tx0", "tx1", "tx2", "tx3", "tx4"}, txIDs(txs), "should skip duplicates caused by page shifts")
+}
+
+func TestTxHistoryStop(t *testing.T) {
+	history := mockHistory(10)
+	server, client, _ := newRouteServer(t, routes{"/v1/txhistory/": historyRoute(history, nil)})
+	defer server.Close()
+
+	txs, err := client.TxHistory(HistoryOptions{PageSize: 3, StopAtTxID: "tx4"}).All()
+	assert.NoError(t, err, "should walk history")
+	assert.Equal(t, []string{"tx0", "tx1", "tx2", "tx3"}, txIDs(txs), "should stop at known transaction")
+
+	stopBefore := time.Unix(int64(history[2].Time), 0)
+	txs, err = client.TxHistory(HistoryOptions{PageSize: 3, StopBefore: stopBefore, Prefetch: 1}).All()
+	assert.NoError(t, err, "should walk history")
+	assert.Equal(t, []string{"tx0", "tx1", "tx2"}, txIDs(txs), "should stop at older transaction")
+}
+
+func TestTxHistoryErrors(t *testing.T) {
+	for _, prefetch := range []int{0, 1} {
+		server, client := newClientServer(t, http.StatusInternalServerError, nil)
+		it := client.TxHistory(HistoryOptions{Prefetch: prefetch})
+		assert.False(t, it.Next(), "should stop on error")
+		assert.Nil(t, it.Tx(), "should not return transaction")
+		assert.Error(t, it.Err(), "should return error")
+
+		txs, err := client.TxHistory(HistoryOptions{Prefetch: prefetch}).All()
+		assert.Nil(t, txs, "should not return transactions")
+		assert.Error(t, err, "should return error")
+		server.Close()
+	}
+}
+
+func TestTxHistoryClose(t *testing.T) {
+	history := mockHistory(20)
+	server, client, _ := newRouteServer(t, routes{"/v1/txhistory/": historyRoute(history, nil)})
+	defer server.Close()
+
+	it := client.TxHistory(HistoryOptions{PageSize: 2, Prefetch: 1})
+	assert.True(t, it.Next(), "should return first transaction")
+	assert.Equal(t, "tx0", it.Tx().TxID, "should return newest transaction")
+
+	it.Close()
+	assert.False(t, it.Next(), "should stop after close")
+	assert.NoError(t, it.Err(), "should not return error")
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pavel-main/bws-go/bip21"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/models"
+)
+
+// PaymentRequest is payment details returned by payment protocol server (BitPay JSON Payment Protocol)
+type PaymentRequest struct {
+	Network         string           `json:"network"`         // main or test
+	Currency        string           `json:"currency"`        // BTC or BCH
+	RequiredFeeRate float64          `json:"requiredFeeRate"` // Minimum fee rate in satoshis per byte
+	Outputs         []*PaymentOutput `json:"outputs"`
+	Time            time.Time        `json:"time"`
+	Expires         time.Time        `json:"expires"`
+	Memo            string           `json:"memo"`
+	PaymentURL      string           `json:"paymentUrl"`
+	PaymentID       string           `json:"paymentId"`
+}
+
+// PaymentOutput is single destination of payment request
+type PaymentOutput struct {
+	Amount  int64  `json:"amount"`
+	Address string `json:"address"`
+}
+
+// FetchPaymentRequest downloads payment request and validates it against configured coin and network
+func (c *Client) FetchPaymentRequest(url string) (*PaymentRequest, error) {
+	headers := map[string]string{"Accept": "application/payment-request"}
+	res, err := c.client.Do("GET", url, headers, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := res.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("Payment request error, status: %d", res.StatusCode)
+	}
+
+	request := &PaymentRequest{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		return nil, err
+	}
+
+	if err := request.Validate(c.cfg); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Validate checks that payment request is for configured coin and network, not expired and has valid outputs
+func (r *PaymentRequest) Validate(cfg *config.Config) error {
+	if !strings.EqualFold(r.Currency, cfg.Coin) {
+		return fmt.Errorf("Payment request currency %s does not match wallet", r.Currency)
+	}
+
+	network := "main"
+	if cfg.Network == config.NetworkTest {
+		network = "test"
+	}
+
+	if r.Network != network {
+		return fmt.Errorf("Payment request network %s does not match wallet", r.Network)
+	}
+
+	if !r.Expires.IsZero() && time.Now().After(r.Expires) {
+		return errors.New("Payment request expired")
+	}
+
+	if len(r.Outputs) == 0 {
+		return errors.New("Payment request has no outputs")
+	}
+
+	for _, output := range r.Outputs {
+		if output.Amount <= 0 {
+			return errors.New("Payment request output amount must be positive")
+		}
+
+		if err := bip21.ValidateAddress(output.Address, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TxOutputs converts payment request outputs to proposal outputs
+func (r *PaymentRequest) TxOutputs() []*models.TxOutput {
+	outputs := []*models.TxOutput{}
+	for _, output := range r.Outputs {
+		outputs = append(outputs, models.NewTxOutput(output.Amount, output.Address))
+	}
+
+	return outputs
+}
+
+// TxProposalOptionsFromURI converts payment URI to proposal options, fetching payment request
+// when URI has one. Outputs of payment request take precedence over URI address and amount.
+func (c *Client) TxProposalOptionsFromURI(uri *bip21.URI) (*TxProposalOptions, error) {
+	if err := uri.Validate(c.cfg); err != nil {
+		return nil, err
+	}
+
+	opts := &TxProposalOptions{}
+	if uri.Message != "" {
+		message := uri.Message
+		opts.Message = &message
+	}
+
+	if uri.PaymentRequest == "" {
+		outputs, err := uri.Outputs()
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Outputs = outputs
+		return opts, nil
+	}
+
+	request, err := c.FetchPaymentRequest(uri.PaymentRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Outputs = request.TxOutputs()
+	opts.PayProURL = uri.PaymentRequest
+	opts.NoShuffleOutputs = true
+	if request.RequiredFeeRate > 0 {
+		opts.FeePerKb = uint64(math.Ceil(request.RequiredFeeRate * 1000))
+	}
+
+	if request.Memo != "" {
+		memo := request.Memo
+		opts.Message = &memo
+	}
+
+	return opts, nil
+}
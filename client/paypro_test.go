@@ -0,0 +1,64 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pavel-main/bws-go/bip21"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxProposalOptionsFromURI(t *testing.T) {
+	request := &PaymentRequest{
+		Network:         "test",
+		Currency:        "BTC",
+		RequiredFeeRate: 12.5,
+		Outputs:         []*PaymentOutput{{Amount: 25000, Address: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"}},
+		Expires:         time.Now().Add(time.Hour),
+		Memo:            "Invoice 42",
+	}
+
+	accept := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		accept = req.Header.Get("Accept")
+		json.NewEncoder(res).Encode(request)
+	}))
+	defer server.Close()
+
+	_, client := newClientServer(t, 200, nil)
+	client.cfg.InsecureTLS = true
+	client, _ = New(client.cfg, client.keys)
+	opts, err := client.TxProposalOptionsFromURI(&bip21.URI{Address: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", Amount: 1000, Message: "rent"})
+	assert.NoError(t, err, "should convert URI without payment request")
+	assert.Equal(t, int64(1000), opts.Outputs[0].Amount)
+	assert.Equal(t, "rent", *opts.Message)
+	assert.NoError(t, opts.Validate(client.cfg.Coin), "should produce valid options")
+
+	opts, err = client.TxProposalOptionsFromURI(&bip21.URI{PaymentRequest: server.URL + "/i/42"})
+	assert.NoError(t, err, "should fetch payment request")
+	assert.Equal(t, "application/payment-request", accept)
+	assert.Equal(t, int64(25000), opts.Outputs[0].Amount, "should pay requested amount")
+	assert.Equal(t, uint64(12500), opts.FeePerKb, "should pay required fee rate")
+	assert.Equal(t, server.URL+"/i/42", opts.PayProURL)
+	assert.Equal(t, "Invoice 42", *opts.Message)
+
+	_, err = client.TxProposalOptionsFromURI(&bip21.URI{PaymentRequest: "http://merchant.example/i/42"})
+	assert.Error(t, err, "should require https payment request")
+
+	request.Expires = time.Now().Add(-time.Minute)
+	_, err = client.TxProposalOptionsFromURI(&bip21.URI{PaymentRequest: server.URL})
+	assert.Error(t, err, "should reject expired request")
+
+	request.Expires = time.Time{}
+	request.Network = "main"
+	_, err = client.FetchPaymentRequest(server.URL)
+	assert.Error(t, err, "should reject request for other network")
+
+	request.Network = "test"
+	request.Outputs[0].Address = "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"
+	_, err = client.FetchPaymentRequest(server.URL)
+	assert.Error(t, err, "should reject output address of other network")
+}
@@ -0,0 +1,288 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/models"
+)
+
+const defaultFeeLevel = "normal"
+
+// TxProposalOptions configures transaction proposal creation
+type TxProposalOptions struct {
+	Outputs                 []*models.TxOutput // Destinations and optional OP_RETURN data output, single output without amount when SendMax is set
+	FeeLevel                string             // Named fee level, defaults to normal unless FeePerKb or Fee is set
+	FeePerKb                uint64             // Custom fee rate in satoshis per kilobyte
+	Fee                     int64              // Absolute fee, requires explicit Inputs
+	Message                 *string            // Proposal message
+	PayProURL               string             // Payment protocol request URL
+	ExcludeUnconfirmedUtxos bool               // Only spend confirmed coins
+	Inputs                  []*models.TxInput  // Spend exactly these coins, custom sequences must match ones BWS derives
+	SendMax                 bool               // Spend all available (or listed) coins to single output
+	NoShuffleOutputs        bool               // Keep outputs in given order
+	Replaceable             bool               // Signal opt-in replace-by-fee (BIP125)
+	LockTime                uint32             // Block height before which transaction cannot be mined
+	ReplaceTxByFee          bool               // Allow spending inputs of unconfirmed replaceable transaction
+	CustomData              interface{}        // Arbitrary data stored with proposal
+	TxProposalID            string             // Client-chosen ID, repeated requests return the same proposal
+	DryRun                  bool               // Only estimate proposal without storing it
+	APIVersion              int                // Endpoint version, 2 (default) or 3
+}
+
+// Validate rejects incomplete or conflicting options before request is sent
+func (opts *TxProposalOptions) Validate(coin string) error {
+	if len(opts.Outputs) == 0 {
+		return errors.New("No outputs specified")
+	}
+
+	dataOutputs := 0
+	for _, output := range opts.Outputs {
+		if output != nil && output.IsData() {
+			if err := output.ValidateData(coin); err != nil {
+				return err
+			}
+
+			dataOutputs++
+			continue
+		}
+
+		if output == nil || output.ToAddress == "" {
+			return errors.New("Output address not specified")
+		}
+
+		if output.Amount < 0 || (output.Amount == 0 && !opts.SendMax) {
+			return errors.New("Output amount must be positive")
+		}
+	}
+
+	if dataOutputs > 1 {
+		return errors.New("Only one data output is allowed")
+	}
+
+	if opts.SendMax {
+		if len(opts.Outputs) != 1 || dataOutputs != 0 {
+			return errors.New("Send max requires single output")
+		}
+
+		if opts.Outputs[0].Amount != 0 {
+			return errors.New("Output amount is calculated when sending max")
+		}
+	}
+
+	fees := 0
+	for _, set := range []bool{opts.FeeLevel != "", opts.FeePerKb != 0, opts.Fee != 0} {
+		if set {
+			fees++
+		}
+	}
+
+	if fees > 1 {
+		return errors.New("Only one of fee level, fee per KB or fee may be specified")
+	}
+
+	if opts.Fee < 0 {
+		return errors.New("Fee must not be negative")
+	}
+
+	if opts.Fee != 0 && len(opts.Inputs) == 0 {
+		return errors.New("Absolute fee requires explicit inputs")
+	}
+
+	if opts.ExcludeUnconfirmedUtxos && len(opts.Inputs) != 0 {
+		return errors.New("Unconfirmed coins cannot be excluded from explicit inputs")
+	}
+
+	if (opts.Replaceable || opts.ReplaceTxByFee) && coin == config.CoinBCH {
+		return errors.New("Replace-by-fee is not supported by BCH")
+	}
+
+	if opts.ReplaceTxByFee && len(opts.Inputs) == 0 {
+		return errors.New("Replacement requires explicit inputs")
+	}
+
+	// Inputs without custom sequence enable lock time by default
+	final := len(opts.Inputs) != 0
+	for _, input := range opts.Inputs {
+		if input.Sequence == nil || *input.Sequence != wire.MaxTxInSequenceNum {
+			final = false
+		}
+
+		if input.Sequence != nil && opts.Replaceable && *input.Sequence > models.RBFSequence {
+			return fmt.Errorf("Input %s sequence does not signal replace-by-fee", input.Outpoint())
+		}
+	}
+
+	if opts.LockTime != 0 && final {
+		return errors.New("Lock time requires at least one non-final input sequence")
+	}
+
+	if opts.LockTime >= models.LockTimeThreshold {
+		return errors.New("Lock time as date is only supported by recovery transactions, proposals lock until block height")
+	}
+
+	if opts.APIVersion != 0 && opts.APIVersion != 2 && opts.APIVersion != 3 {
+		return errors.New("Only API versions 2 and 3 are supported")
+	}
+
+	return nil
+}
+
+// payload returns request body with options that are set
+func (opts *TxProposalOptions) payload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"outputs": opts.Outputs,
+		"dryRun":  opts.DryRun,
+	}
+
+	switch {
+	case opts.FeePerKb != 0:
+		payload["feePerKb"] = opts.FeePerKb
+	case opts.Fee != 0:
+		payload["fee"] = opts.Fee
+	case opts.FeeLevel != "":
+		payload["feeLevel"] = opts.FeeLevel
+	default:
+		payload["feeLevel"] = defaultFeeLevel
+	}
+
+	if opts.Message != nil {
+		payload["message"] = *opts.Message
+	}
+
+	if opts.PayProURL != "" {
+		payload["payProUrl"] = opts.PayProURL
+	}
+
+	if opts.ExcludeUnconfirmedUtxos {
+		payload["excludeUnconfirmedUtxos"] = true
+	}
+
+	if len(opts.Inputs) != 0 {
+		payload["inputs"] = opts.Inputs
+	}
+
+	if opts.SendMax {
+		payload["sendMax"] = true
+	}
+
+	if opts.NoShuffleOutputs {
+		payload["noShuffleOutputs"] = true
+	}
+
+	if opts.Replaceable {
+		payload["enableRBF"] = true
+	}
+
+	if opts.LockTime != 0 {
+		payload["lockUntilBlockHeight"] = opts.LockTime
+	}
+
+	if opts.ReplaceTxByFee {
+		payload["replaceTxByFee"] = true
+	}
+
+	if opts.CustomData != nil {
+		payload["customData"] = opts.CustomData
+	}
+
+	if opts.TxProposalID != "" {
+		payload["txProposalId"] = opts.TxProposalID
+	}
+
+	return payload
+}
+
+// path returns endpoint for requested API version
+func (opts *TxProposalOptions) path() string {
+	if opts.APIVersion == 3 {
+		return "/v3/txproposals/"
+	}
+
+	return "/v2/txproposals/"
+}
+
+// CreateTxProposalWithOptions validates options and creates transaction proposal
+func (c *Client) CreateTxProposalWithOptions(opts *TxProposalOptions) (*models.TxProposal, error) {
+	if err := opts.Validate(c.cfg.Coin); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkFrozen(opts.Inputs); err != nil {
+		return nil, err
+	}
+
+	payload := opts.payload()
+	if len(opts.Inputs) == 0 {
+		frozen, err := c.frozenOutpoints()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(frozen) != 0 {
+			payload["utxosToExclude"] = frozen
+		}
+	}
+
+	bytes, err := c.doPostRequest(opts.path(), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.TxProposal{}
+	if err := json.Unmarshal(bytes, response); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkFrozenProposal(response, opts.DryRun); err != nil {
+		return nil, err
+	}
+
+	// Proposal signed with other lock time or sequences would not match
+	if response.LockTime != opts.LockTime {
+		err := fmt.Errorf("Proposal lock time %d does not match requested %d", response.LockTime, opts.LockTime)
+		return nil, c.discardTxProposal(response, opts.DryRun, err)
+	}
+
+	if err := checkSequences(response, opts.Inputs); err != nil {
+		return nil, c.discardTxProposal(response, opts.DryRun, err)
+	}
+
+	return response, nil
+}
+
+// checkSequences fails if proposal inputs would not use custom sequences of requested inputs,
+// BWS derives sequences from lock time and RBF flag, ignoring requested ones
+func checkSequences(txp *models.TxProposal, requested []*models.TxInput) error {
+	sequences := map[string]uint32{}
+	for _, input := range requested {
+		if input.Sequence != nil {
+			sequences[input.Outpoint()] = *input.Sequence
+		}
+	}
+
+	for _, input := range txp.Inputs {
+		expected, ok := sequences[input.Outpoint()]
+		if sequence := txp.InputSequence(input); ok && sequence != expected {
+			return fmt.Errorf("Proposal input %s sequence %d does not match requested %d", input.Outpoint(), sequence, expected)
+		}
+	}
+
+	return nil
+}
+
+// discardTxProposal removes stored proposal rejected by client, returning reason of rejection
+func (c *Client) discardTxProposal(txp *models.TxProposal, dryRun bool, reason error) error {
+	if dryRun || txp.ID == "" {
+		return reason
+	}
+
+	if _, err := c.RemoveTxProposal(txp.ID); err != nil {
+		return fmt.Errorf("%v, removing proposal %s failed: %v", reason, txp.ID, err)
+	}
+
+	return reason
+}
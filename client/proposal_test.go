@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockTxInput = &models.TxInput{
+	TxID:     "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4",
+	Vout:     1,
+	Satoshis: 14110412,
+}
+
+func TestTxProposalOptionsValidate(t *testing.T) {
+	to := "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"
+	data, _ := models.NewDataOutput([]byte("hello"))
+	final := &models.TxInput{TxID: mockTxInput.TxID, Vout: 1, Satoshis: 1000, Sequence: pointer.ToUint32(wire.MaxTxInSequenceNum)}
+	large, _ := models.NewDataOutput(bytes.Repeat([]byte{1}, models.MaxDataSizeBTC+1))
+	valid := []*TxProposalOptions{
+		{Outputs: models.NewTxOutputSingle(1000, to)},
+		{Outputs: models.NewTxOutputSingle(1000, to), FeePerKb: 2000, Replaceable: true},
+		{Outputs: models.NewTxOutputSingle(0, to), SendMax: true, Inputs: []*models.TxInput{mockTxInput}},
+		{Outputs: models.NewTxOutputSingle(1000, to), Fee: 500, Inputs: []*models.TxInput{mockTxInput}},
+		{Outputs: models.NewTxOutputSingle(1000, to), APIVersion: 3},
+		{Outputs: append(models.NewTxOutputSingle(1000, to), data)},
+		{Outputs: models.NewTxOutputSingle(1000, to), LockTime: 1500000},
+		{Outputs: models.NewTxOutputSingle(1000, to), LockTime: 1500000, Fee: 500, Inputs: []*models.TxInput{mockTxInput, final}},
+	}
+
+	for _, opts := range valid {
+		assert.NoError(t, opts.Validate(config.CoinBTC), "should accept valid options")
+	}
+
+	invalid := []*TxProposalOptions{
+		{},
+		{Outputs: models.NewTxOutputSingle(0, to)},
+		{Outputs: models.NewTxOutputSingle(1000, "")},
+		{Outputs: models.NewTxOutputSingle(1000, to), SendMax: true},
+		{Outputs: append(models.NewTxOutputSingle(0, to), models.NewTxOutput(0, to)), SendMax: true},
+		{Outputs: models.NewTxOutputSingle(1000, to), FeeLevel: "normal", FeePerKb: 2000},
+		{Outputs: models.NewTxOutputSingle(1000, to), Fee: 500},
+		{Outputs: models.NewTxOutputSingle(1000, to), ExcludeUnconfirmedUtxos: true, Inputs: []*models.TxInput{mockTxInput}},
+		{Outputs: models.NewTxOutputSingle(1000, to), APIVersion: 1},
+		{Outputs: append(models.NewTxOutputSingle(1000, to), data, data)},
+		{Outputs: append(models.NewTxOutputSingle(1000, to), large)},
+		{Outputs: append(models.NewTxOutputSingle(0, to), data), SendMax: true},
+		{Outputs: []*models.TxOutput{data}, SendMax: true},
+		{Outputs: models.NewTxOutputSingle(1000, to), LockTime: 1500000, Fee: 500, Inputs: []*models.TxInput{final}},
+		{Outputs: models.NewTxOutputSingle(1000, to), Replaceable: true, Fee: 500, Inputs: []*models.TxInput{final}},
+		{Outputs: models.NewTxOutputSingle(1000, to), LockTime: 1600000000},
+	}
+
+	for _, opts := range invalid {
+		assert.Error(t, opts.Validate(config.CoinBTC), "should reject invalid options")
+	}
+
+	rbf := &TxProposalOptions{Outputs: models.NewTxOutputSingle(1000, to), Replaceable: true}
+	assert.Error(t, rbf.Validate(config.CoinBCH), "should reject RBF for BCH")
+}
+
+func TestCreateTxProposalWithOptions(t *testing.T) {
+	expected := &models.TxProposal{ID: "txp", Amount: 1000}
+	server, client, log := newRouteServer(t, routes{"": respond(200, expected)})
+	defer server.Close()
+
+	txp, err := client.CreateTxProposalWithOptions(&TxProposalOptions{
+		Outputs:          models.NewTxOutputSingle(1000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		FeePerKb:         2000,
+		Message:          pointer.ToString("rent"),
+		NoShuffleOutputs: true,
+		Replaceable:      true,
+		CustomData:       map[string]string{"order": "42"},
+		TxProposalID:     "client-id",
+		APIVersion:       3,
+	})
+
+	assert.NoError(t, err, "should create proposal")
+	assert.Equal(t, expected, txp, "proposals should match")
+	assert.Equal(t, []string{"POST /v3/txproposals/"}, log.Calls(), "should use requested API version")
+
+	payload := log.Requests()[0].Payload
+	assert.Equal(t, float64(2000), payload["feePerKb"], "should send fee rate")
+	assert.NotContains(t, payload, "feeLevel", "should not send default fee level with fee rate")
+	assert.Equal(t, "rent", payload["message"])
+	assert.Equal(t, true, payload["noShuffleOutputs"])
+	assert.Equal(t, true, payload["enableRBF"])
+	assert.Equal(t, map[string]interface{}{"order": "42"}, payload["customData"])
+	assert.Equal(t, "client-id", payload["txProposalId"])
+	assert.NotContains(t, payload, "sendMax", "should not send unset options")
+
+	_, err = client.CreateTxProposalWithOptions(&TxProposalOptions{})
+	assert.Error(t, err, "should reject invalid options")
+	assert.Len(t, log.Requests(), 1, "should not send invalid request")
+}
+
+func TestCreateTxProposalWithLockTime(t *testing.T) {
+	server, client, log := newRouteServer(t, routes{"": respond(200, &models.TxProposal{ID: "txp", LockTime: 1500000})})
+	defer server.Close()
+
+	txp, err := client.CreateTxProposalWithOptions(&TxProposalOptions{
+		Outputs:  models.NewTxOutputSingle(1000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		LockTime: 1500000,
+	})
+
+	assert.NoError(t, err, "should create timelocked proposal")
+	assert.Equal(t, uint32(1500000), txp.LockTime)
+	assert.Equal(t, float64(1500000), log.Requests()[0].Payload["lockUntilBlockHeight"], "should send lock block height")
+
+	_, err = client.CreateTxProposalWithOptions(&TxProposalOptions{
+		Outputs:  models.NewTxOutputSingle(1000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		LockTime: 1600000,
+	})
+
+	assert.Error(t, err, "should reject proposal with other lock time")
+	assert.Equal(t, "DELETE /v1/txproposals/txp", log.Calls()[2], "should remove mismatching proposal")
+}
+
+func TestCreateTxProposalWithSequences(t *testing.T) {
+	input := *mockTxInput
+	response := &models.TxProposal{ID: "txp", LockTime: 1500000, Inputs: []*models.TxInput{&input}}
+	server, client, log := newRouteServer(t, routes{"": respond(200, response)})
+	defer server.Close()
+
+	requested := input
+	requested.Sequence = pointer.ToUint32(models.LockTimeSequence)
+	opts := &TxProposalOptions{
+		Outputs:  models.NewTxOutputSingle(1000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		Fee:      500,
+		Inputs:   []*models.TxInput{&requested},
+		LockTime: 1500000,
+	}
+
+	_, err := client.CreateTxProposalWithOptions(opts)
+	assert.NoError(t, err, "should accept sequence derived by server")
+
+	requested.Sequence = pointer.ToUint32(10)
+	_, err = client.CreateTxProposalWithOptions(opts)
+	assert.Error(t, err, "should reject proposal ignoring custom sequence")
+	assert.Equal(t, "DELETE /v1/txproposals/txp", log.Calls()[len(log.Calls())-1], "should remove mismatching proposal")
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pavel-main/bws-go/fees"
+	"github.com/pavel-main/bws-go/models"
+)
+
+// ErrNotReplaceable is returned when transaction cannot be replaced by fee
+var ErrNotReplaceable = errors.New("Transaction does not signal replace-by-fee")
+
+const (
+	incrementalRelayFeePerKb = 1000 // Minimal fee rate increase accepted by nodes (BIP125)
+	dustLimit                = 546
+	statusBroadcasted        = "broadcasted"
+	statusAccepted           = "accepted"
+)
+
+// BumpFeeOptions configures replace-by-fee workflow
+type BumpFeeOptions struct {
+	FeeLevel  string // Named fee level of replacement, defaults to normal unless FeePerKb is set
+	FeePerKb  uint64 // Custom fee rate of replacement in satoshis per kilobyte
+	Sign      bool   // Sign replacement after publishing
+	Broadcast bool   // Broadcast replacement once it collects enough signatures, requires Sign
+}
+
+// BumpTxFee replaces broadcast transaction from history, see BumpFee
+func (c *Client) BumpTxFee(tx *models.Transaction, opts BumpFeeOptions) (*models.TxProposal, error) {
+	if tx.Confirmations > 0 {
+		return nil, errors.New("Transaction is already confirmed")
+	}
+
+	if tx.ProposalID == "" {
+		return nil, errors.New("Transaction was not created by wallet proposal")
+	}
+
+	original, err := c.GetTx(tx.ProposalID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.BumpFee(original, opts)
+}
+
+// BumpFee creates replacement of broadcast proposal spending the same inputs to the same recipients
+// at higher fee rate, paid from change, then publishes, signs and broadcasts it as requested
+func (c *Client) BumpFee(original *models.TxProposal, opts BumpFeeOptions) (*models.TxProposal, error) {
+	if !original.EnableRBF {
+		return nil, ErrNotReplaceable
+	}
+
+	if original.Status != statusBroadcasted {
+		return nil, fmt.Errorf("Only broadcast proposals can be replaced, status: %s", original.Status)
+	}
+
+	if len(original.Inputs) == 0 {
+		return nil, errors.New("Proposal inputs not specified")
+	}
+
+	feePerKb := opts.FeePerKb
+	if feePerKb == 0 {
+		rate, err := c.FeePerKb(opts.FeeLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		feePerKb = rate
+	}
+
+	if feePerKb <= uint64(original.FeePerKB) {
+		return nil, fmt.Errorf("Replacement fee rate %d must be higher than %d", feePerKb, original.FeePerKB)
+	}
+
+	wallet := &models.Wallet{M: uint(original.WalletM), N: uint(original.WalletN), AddressType: original.AddressType}
+	estimator, err := fees.NewEstimator(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	outputSizes, err := fees.OutputSizes(original.Outputs, original.Coin, c.cfg.NetParams())
+	if err != nil {
+		return nil, err
+	}
+
+	vsize := estimator.PaymentVSize(len(original.Inputs), outputSizes, original.ChangeAddress != nil)
+	fee := fees.Fee(vsize, feePerKb)
+	if min := MinReplacementFee(original, vsize); fee < min {
+		fee = min
+	}
+
+	// Fee increase is paid from change, recipients get the same amounts
+	var change int64
+	for _, input := range original.Inputs {
+		change += input.Satoshis
+	}
+
+	change -= original.Amount + fee
+	if original.ChangeAddress == nil || change < dustLimit {
+		return nil, errors.New("Not enough change to pay higher fee")
+	}
+
+	recipients := []*models.TxOutput{}
+	for _, output := range original.Outputs {
+		recipients = append(recipients, &models.TxOutput{
+			Amount:    output.Amount,
+			ToAddress: output.ToAddress,
+			Message:   output.Message,
+			Script:    output.Script,
+		})
+	}
+
+	replacement, err := c.CreateTxProposalWithOptions(&TxProposalOptions{
+		Outputs:        recipients,
+		Inputs:         original.Inputs,
+		Fee:            fee,
+		Message:        original.Message,
+		LockTime:       original.LockTime,
+		Replaceable:    true,
+		ReplaceTxByFee: true,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateReplacement(original, replacement, vsize); err != nil {
+		return nil, c.discardTxProposal(replacement, false, err)
+	}
+
+	if replacement, err = c.PublishTxProposal(replacement); err != nil {
+		return nil, err
+	}
+
+	if !opts.Sign {
+		return replacement, nil
+	}
+
+	if replacement, err = c.SignTxProposal(replacement); err != nil {
+		return nil, err
+	}
+
+	if opts.Broadcast && replacement.Status == statusAccepted {
+		return c.BroadcastTxProposal(replacement.ID)
+	}
+
+	return replacement, nil
+}
+
+// MinReplacementFee returns the lowest absolute fee replacing original,
+// which must pay for its own relay on top of the original fee (BIP125 rule 4)
+func MinReplacementFee(original *models.TxProposal, vsize int) int64 {
+	return original.Fee + fees.Fee(vsize, incrementalRelayFeePerKb)
+}
+
+// ValidateReplacement checks that replacement conflicts with original, keeps signalling RBF,
+// pays the same recipients and sufficiently higher absolute fee
+func ValidateReplacement(original, replacement *models.TxProposal, vsize int) error {
+	if !replacement.EnableRBF {
+		return errors.New("Replacement does not signal replace-by-fee")
+	}
+
+	if !sameInputs(replacement.Inputs, original.Inputs) {
+		return errors.New("Replacement must spend the same inputs")
+	}
+
+	if replacement.Amount != original.Amount {
+		return errors.New("Replacement must pay the same amount")
+	}
+
+	if len(replacement.Outputs) != len(original.Outputs) {
+		return errors.New("Replacement must pay the same recipients")
+	}
+
+	for idx, output := range original.Outputs {
+		other := replacement.Outputs[idx]
+		if other.ToAddress != output.ToAddress || other.Amount != output.Amount || !strings.EqualFold(other.Script, output.Script) {
+			return fmt.Errorf("Replacement output %d does not match original", idx)
+		}
+	}
+
+	if min := MinReplacementFee(original, vsize); replacement.Fee < min {
+		return fmt.Errorf("Replacement fee %d is lower than required %d", replacement.Fee, min)
+	}
+
+	return nil
+}
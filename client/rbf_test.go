@@ -0,0 +1,152 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockReplaceable() *models.TxProposal {
+	input := *mockTxInput
+	input.Path = "m/0/0"
+	input.ScriptPubKey = "76a9143874e8eb8a2e018c46721fcdd8e9049f619af35488ac"
+	return &models.TxProposal{
+		ID:            "original",
+		TxID:          "f00d",
+		Status:        "broadcasted",
+		WalletM:       1,
+		WalletN:       1,
+		AddressType:   "P2PKH",
+		Amount:        1000000,
+		Fee:           226,
+		FeePerKB:      1000,
+		EnableRBF:     true,
+		OutputOrder:   []int{0, 1},
+		Inputs:        []*models.TxInput{&input},
+		Outputs:       models.NewTxOutputSingle(1000000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		ChangeAddress: &models.Address{Address: "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK"},
+	}
+}
+
+// newReplacementServer drives replacement through proposal lifecycle, tamper may alter created proposal
+func newReplacementServer(t *testing.T, original *models.TxProposal, tamper func(txp *models.TxProposal)) (*httptest.Server, *Client, *serverLog) {
+	var replacement models.TxProposal
+	update := func(change func(payload map[string]interface{})) http.HandlerFunc {
+		return func(res http.ResponseWriter, req *http.Request) {
+			payload := map[string]interface{}{}
+			json.NewDecoder(req.Body).Decode(&payload)
+			change(payload)
+			json.NewEncoder(res).Encode(&replacement)
+		}
+	}
+
+	routes := routes{
+		"/v1/txproposals/original": respond(http.StatusOK, original),
+		"/v2/txproposals/": update(func(payload map[string]interface{}) {
+			replacement = *original
+			replacement.ID = "replacement"
+			replacement.CreatorID = "me"
+			replacement.Status = "temporary"
+			replacement.Fee = int64(payload["fee"].(float64))
+			if tamper != nil {
+				tamper(&replacement)
+			}
+		}),
+		"/v1/txproposals/*/publish/": update(func(payload map[string]interface{}) {
+			replacement.CreatorSignature = payload["proposalSignature"].(string)
+			replacement.Status = "pending"
+		}),
+		"/v1/txproposals/*/signatures/": update(func(map[string]interface{}) { replacement.Status = "accepted" }),
+		"/v1/txproposals/*/broadcast/":  update(func(map[string]interface{}) { replacement.Status = "broadcasted" }),
+		"":                              update(func(map[string]interface{}) {}),
+	}
+
+	server, client, log := newRouteServer(t, routes)
+	routes["/v2/wallets/"] = respondWallet(t, ownWallet(client))
+	return server, client, log
+}
+
+func TestBumpFee(t *testing.T) {
+	original := mockReplaceable()
+	server, client, log := newReplacementServer(t, original, nil)
+	defer server.Close()
+
+	tx := &models.Transaction{TxID: "f00d", ProposalID: "original", LowFees: true}
+	replacement, err := client.BumpTxFee(tx, BumpFeeOptions{FeePerKb: 5000, Sign: true, Broadcast: true})
+	assert.NoError(t, err, "should replace transaction")
+	assert.Equal(t, "broadcasted", replacement.Status, "should broadcast replacement")
+	assert.Equal(t, int64(1130), replacement.Fee, "should pay higher fee rate")
+	assert.Equal(t, []string{
+		"GET /v1/txproposals/original",
+		"POST /v2/txproposals/",
+		"POST /v1/txproposals/replacement/publish/",
+		"GET /v2/wallets/",
+		"POST /v1/txproposals/replacement/signatures/",
+		"POST /v1/txproposals/replacement/broadcast/",
+	}, log.Calls(), "should drive replacement through publish, verification, sign and broadcast")
+}
+
+func TestBumpFeeValidation(t *testing.T) {
+	plain, client := newClientServer(t, http.StatusOK, nil)
+	defer plain.Close()
+
+	original := mockReplaceable()
+	original.EnableRBF = false
+	_, err := client.BumpFee(original, BumpFeeOptions{FeePerKb: 5000})
+	assert.Equal(t, ErrNotReplaceable, err, "should require RBF signalling")
+
+	original = mockReplaceable()
+	_, err = client.BumpFee(original, BumpFeeOptions{FeePerKb: 1000})
+	assert.Error(t, err, "should require higher fee rate")
+
+	original.ChangeAddress = nil
+	_, err = client.BumpFee(original, BumpFeeOptions{FeePerKb: 5000})
+	assert.Error(t, err, "should require change to pay fee")
+
+	// Server replaced inputs
+	server, client, log := newReplacementServer(t, mockReplaceable(), func(txp *models.TxProposal) {
+		other := *txp.Inputs[0]
+		other.Vout = 5
+		txp.Inputs = []*models.TxInput{&other}
+	})
+	defer server.Close()
+
+	_, err = client.BumpFee(mockReplaceable(), BumpFeeOptions{FeePerKb: 5000})
+	assert.Error(t, err, "should reject replacement spending other inputs")
+	assert.Equal(t, "DELETE /v1/txproposals/replacement", log.Calls()[len(log.Calls())-1], "should remove invalid replacement")
+}
+
+func TestValidateReplacement(t *testing.T) {
+	original := mockReplaceable()
+	replacement := mockReplaceable()
+
+	replacement.Fee = MinReplacementFee(original, 226)
+	assert.NoError(t, ValidateReplacement(original, replacement, 226), "should accept replacement")
+
+	replacement.Fee--
+	assert.Error(t, ValidateReplacement(original, replacement, 226), "should require fee paying for relay")
+
+	replacement.Fee = 5000
+	replacement.Amount--
+	assert.Error(t, ValidateReplacement(original, replacement, 226), "should require the same amount")
+
+	replacement.Amount++
+	replacement.Outputs = models.NewTxOutputSingle(1000000, "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK")
+	assert.Error(t, ValidateReplacement(original, replacement, 226), "should pay the same address")
+
+	replacement.Outputs = mockReplaceable().Outputs
+	replacement.Outputs[0].Amount--
+	assert.Error(t, ValidateReplacement(original, replacement, 226), "should pay the same output amounts")
+
+	replacement.Outputs = mockReplaceable().Outputs
+	replacement.Outputs = append(replacement.Outputs, &models.TxOutput{Script: "6a0568656c6c6f"})
+	assert.Error(t, ValidateReplacement(original, replacement, 226), "should keep the same data outputs")
+
+	replacement.Outputs = mockReplaceable().Outputs
+	replacement.EnableRBF = false
+	assert.Error(t, ValidateReplacement(original, replacement, 226), "should keep signalling RBF")
+}
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/socketio"
+)
+
+// ErrUnauthorized is returned when BWS rejects socket authorization
+var ErrUnauthorized = errors.New("Socket authorization rejected")
+
+// ErrRealtimeUnavailable is reported via OnError when Realtime falls back to polling
+var ErrRealtimeUnavailable = errors.New("Realtime notifications unavailable, falling back to polling")
+
+const (
+	defaultSocketPath        = "/socket.io/"
+	defaultReconnectDelay    = time.Second
+	defaultMaxReconnectDelay = time.Minute
+	defaultMaxAttempts       = 5
+)
+
+// RealtimeOptions configures socket notifications
+type RealtimeOptions struct {
+	Path              string        // Socket.IO path on BWS host, defaults to /socket.io/
+	IncludeOwn        bool          // Deliver notifications caused by this copayer
+	Buffer            int           // Channel capacity, 0 means every notification waits for consumer
+	ReconnectDelay    time.Duration // First reconnection delay, doubled after every failure, defaults to 1 second
+	MaxReconnectDelay time.Duration // Reconnection delay limit, defaults to 1 minute
+	MaxAttempts       int           // Consecutive failures before falling back to polling, defaults to 5
+	PollInterval      time.Duration // Fallback polling interval
+	Store             CursorStore   // Cursor shared with fallback polling, defaults to MemoryCursorStore
+	OnError           func(error)   // Called on connection failures
+}
+
+// Realtime receives notifications pushed by BWS over Socket.IO
+type Realtime struct {
+	client        *Client
+	opts          RealtimeOptions
+	http          *http.Client
+	notifications chan *models.Notification
+}
+
+// NewRealtime creates socket notifications receiver, call Run to connect
+func (c *Client) NewRealtime(opts RealtimeOptions) *Realtime {
+	if opts.Path == "" {
+		opts.Path = defaultSocketPath
+	}
+
+	if opts.ReconnectDelay <= 0 {
+		opts.ReconnectDelay = defaultReconnectDelay
+	}
+
+	if opts.MaxReconnectDelay <= 0 {
+		opts.MaxReconnectDelay = defaultMaxReconnectDelay
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+
+	if opts.Store == nil {
+		opts.Store = &MemoryCursorStore{}
+	}
+
+	// Long-polling requests last up to ping interval, so only connecting is limited
+	dialer := &net.Dialer{Timeout: time.Duration(c.cfg.Timeout) * time.Millisecond}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: dialer.Timeout,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: c.cfg.InsecureTLS},
+	}
+
+	if c.cfg.Proxy != "" {
+		if proxy, err := url.Parse(c.cfg.ProxyURL()); err == nil {
+			transport.Proxy = http.ProxyURL(proxy)
+		}
+	}
+
+	r := new(Realtime)
+	r.client = c
+	r.opts = opts
+	r.http = &http.Client{Transport: transport}
+	r.notifications = make(chan *models.Notification, opts.Buffer)
+	return r
+}
+
+// Notifications returns channel closed when Run returns
+func (r *Realtime) Notifications() <-chan *models.Notification {
+	return r.notifications
+}
+
+// Run keeps socket connected, reconnecting with backoff, until context is cancelled.
+// After MaxAttempts consecutive failures it falls back to polling GetNotifications.
+func (r *Realtime) Run(ctx context.Context) error {
+	defer close(r.notifications)
+
+	failures := 0
+	delay := r.opts.ReconnectDelay
+	for {
+		authorized, err := r.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err == ErrUnauthorized {
+			return err
+		}
+
+		r.report(err)
+		if authorized {
+			failures = 0
+			delay = r.opts.ReconnectDelay
+		}
+
+		failures++
+		if failures >= r.opts.MaxAttempts {
+			r.report(ErrRealtimeUnavailable)
+			return r.poll(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > r.opts.MaxReconnectDelay {
+			delay = r.opts.MaxReconnectDelay
+		}
+	}
+}
+
+// session connects, answers BWS challenge and delivers notifications until connection fails
+func (r *Realtime) session(ctx context.Context) (bool, error) {
+	endpoint, err := r.endpoint()
+	if err != nil {
+		return false, err
+	}
+
+	conn, err := socketio.Dial(ctx, endpoint, r.http)
+	if err != nil {
+		return false, err
+	}
+
+	defer conn.Close()
+
+	copayerID := hex.EncodeToString(r.client.xPubToCopayerID())
+	authorized := false
+	delivered := map[string]bool{}
+	for {
+		var event *socketio.Event
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return authorized, ctx.Err()
+		case event, ok = <-conn.Events():
+		}
+
+		if !ok {
+			return authorized, conn.Err()
+		}
+
+		switch event.Name {
+		case "challenge":
+			var nonce string
+			if err := event.Decode(0, &nonce); err != nil {
+				return authorized, err
+			}
+
+			signature, err := r.client.signMessage(nonce)
+			if err != nil {
+				return authorized, err
+			}
+
+			auth := map[string]string{
+				"copayerId": copayerID,
+				"message":   nonce,
+				"signature": hex.EncodeToString(signature),
+			}
+
+			if err := conn.Emit("authorize", auth); err != nil {
+				return authorized, err
+			}
+		case "authorized":
+			authorized = true
+
+			// Notifications sent while disconnected are only available via API
+			if err := r.catchUp(ctx, delivered); err != nil {
+				return false, err
+			}
+		case "unauthorized":
+			return false, ErrUnauthorized
+		case "notification":
+			notification := &models.Notification{}
+			if err := event.Decode(0, notification); err != nil {
+				r.report(err)
+				continue
+			}
+
+			if delivered[notification.ID] {
+				continue
+			}
+
+			if !r.opts.IncludeOwn && notification.CreatorID != nil && *notification.CreatorID == copayerID {
+				continue
+			}
+
+			if err := r.deliver(ctx, notification); err != nil {
+				return authorized, err
+			}
+
+			delivered[notification.ID] = true
+		}
+	}
+}
+
+// catchUp delivers notifications created after stored cursor, remembering their IDs
+// so the same notifications pushed over socket are skipped
+func (r *Realtime) catchUp(ctx context.Context, delivered map[string]bool) error {
+	cursor, err := r.opts.Store.Load()
+	if err != nil || cursor == "" {
+		return err
+	}
+
+	notifications, err := r.client.GetNotifications(cursor, 0, r.opts.IncludeOwn)
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range notifications {
+		if delivered[notification.ID] {
+			continue
+		}
+
+		if err := r.deliver(ctx, notification); err != nil {
+			return err
+		}
+
+		delivered[notification.ID] = true
+	}
+
+	return nil
+}
+
+func (r *Realtime) deliver(ctx context.Context, notification *models.Notification) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case r.notifications <- notification:
+	}
+
+	return r.opts.Store.Save(notification.ID)
+}
+
+// poll forwards notifications from polling Subscriber sharing the same cursor
+func (r *Realtime) poll(ctx context.Context) error {
+	sub := r.client.NewSubscriber(SubscriberOptions{
+		Interval:   r.opts.PollInterval,
+		IncludeOwn: r.opts.IncludeOwn,
+		Store:      r.opts.Store,
+		OnError:    r.opts.OnError,
+	})
+
+	result := make(chan error, 1)
+	go func() { result <- sub.Run(ctx) }()
+
+	for notification := range sub.Notifications() {
+		select {
+		case <-ctx.Done():
+		case r.notifications <- notification:
+		}
+	}
+
+	return <-result
+}
+
+func (r *Realtime) report(err error) {
+	if err != nil && r.opts.OnError != nil {
+		r.opts.OnError(err)
+	}
+}
+
+// endpoint builds Socket.IO URL on BWS host
+func (r *Realtime) endpoint() (string, error) {
+	base, err := url.Parse(r.client.cfg.BaseURL)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := url.URL{Scheme: base.Scheme, Host: base.Host, Path: r.opts.Path}
+	return endpoint.String(), nil
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/socketio"
+	"github.com/pavel-main/bws-go/socketio/sockettest"
+	"github.com/pavel-main/bws-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBWSSocketServer emulates BWS challenge-response authorization and pushes notifications,
+// pushed[i] after i-th authorization
+func newBWSSocketServer(t *testing.T, client *Client, pushed ...[]*models.Notification) *sockettest.Server {
+	var mutex sync.Mutex
+	authorized := 0
+	server := sockettest.NewServer()
+	server.PingInterval = 100 * time.Millisecond
+	server.OnConnect = func(s *sockettest.Session) {
+		s.Emit("challenge", "nonce-"+s.ID)
+	}
+	server.OnEvent = func(s *sockettest.Session, event *socketio.Event) {
+		if event.Name != "authorize" {
+			return
+		}
+
+		auth := map[string]string{}
+		event.Decode(0, &auth)
+		signature, _ := hex.DecodeString(auth["signature"])
+		valid, _ := utils.VerifyMessage([]byte(auth["message"]), signature, client.keys.ReqPubKey)
+		copayerID := hex.EncodeToString(client.xPubToCopayerID())
+		if !valid || auth["message"] != "nonce-"+s.ID || auth["copayerId"] != copayerID {
+			s.Emit("unauthorized")
+			return
+		}
+
+		s.Emit("authorized")
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if authorized < len(pushed) {
+			for _, notification := range pushed[authorized] {
+				s.Emit("notification", notification)
+			}
+		}
+
+		authorized++
+	}
+
+	client.cfg.BaseURL = server.URL + "/bws/api"
+	return server
+}
+
+func TestRealtime(t *testing.T) {
+	apiRoutes := routes{}
+	api, client, log := newRouteServer(t, apiRoutes)
+	defer api.Close()
+
+	own := hex.EncodeToString(client.xPubToCopayerID())
+	notifications := []*models.Notification{
+		{ID: "1538558571001", Type: "NewIncomingTx"},
+		{ID: "1538558571002", Type: "NewTxProposal", CreatorID: pointer.ToString(own)},
+		{ID: "1538558571003", Type: "TxProposalAcceptedBy", CreatorID: pointer.ToString("other")},
+		{ID: "1538558571004", Type: "TxProposalFinallyAccepted"},
+		{ID: "1538558571005", Type: "NewOutgoingTx"},
+	}
+
+	// Notification 4 is created while client is disconnected, server pushes it again after reconnect
+	server := newBWSSocketServer(t, client, notifications[:3], notifications[3:])
+	defer server.Close()
+
+	// BWS serves API and socket on the same host
+	apiRoutes["/bws/api/v1/notifications/"] = notificationsRoute(notifications[:4])
+	apiRoutes["/socket.io/"] = server.Config.Handler.ServeHTTP
+	client.cfg.BaseURL = api.URL + "/bws/api"
+
+	store := &MemoryCursorStore{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	realtime := client.NewRealtime(RealtimeOptions{Store: store, ReconnectDelay: time.Millisecond})
+	result := make(chan error, 1)
+	go func() { result <- realtime.Run(ctx) }()
+
+	first := <-realtime.Notifications()
+	assert.Equal(t, "1538558571001", first.ID, "should deliver pushed notification")
+
+	second := <-realtime.Notifications()
+	assert.Equal(t, "1538558571003", second.ID, "should skip own notifications")
+	assert.Empty(t, log.Find("GET /bws/api/v1/notifications/"), "should not fetch notifications without cursor")
+
+	// Server drops connection, client reconnects, authorizes again and fetches missed notifications
+	server.CloseSessions()
+	third := <-realtime.Notifications()
+	assert.Equal(t, "1538558571004", third.ID, "should deliver notification missed while disconnected")
+	assert.Equal(t, 2, server.Sessions(), "should reconnect")
+	assert.Equal(t, "1538558571003", log.Find("GET /bws/api/v1/notifications/")[0].Query.Get("notificationId"), "should fetch notifications after cursor")
+
+	fourth := <-realtime.Notifications()
+	assert.Equal(t, "1538558571005", fourth.ID, "should deliver fetched notification once")
+
+	cancel()
+	for range realtime.Notifications() {
+	}
+
+	assert.Equal(t, context.Canceled, <-result, "should stop on context cancellation")
+	cursor, _ := store.Load()
+	assert.Equal(t, "1538558571005", cursor, "should persist cursor of delivered notification")
+}
+
+func TestRealtimeUnauthorized(t *testing.T) {
+	api, client := newClientServer(t, 200, nil)
+	defer api.Close()
+
+	server := newBWSSocketServer(t, client)
+	defer server.Close()
+
+	// Challenge answered by client does not match the session one
+	server.OnConnect = func(s *sockettest.Session) {
+		s.Emit("challenge", "forged")
+	}
+
+	realtime := client.NewRealtime(RealtimeOptions{})
+	err := realtime.Run(context.Background())
+	assert.Equal(t, ErrUnauthorized, err, "should stop on rejected authorization")
+
+	_, ok := <-realtime.Notifications()
+	assert.False(t, ok, "should close notifications channel")
+}
+
+func TestRealtimeFallback(t *testing.T) {
+	notifications := []*models.Notification{{ID: "1538558571001", Type: "NewIncomingTx"}}
+	server, client := newClientServer(t, http.StatusOK, notifications)
+	defer server.Close()
+
+	errors := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	realtime := client.NewRealtime(RealtimeOptions{
+		MaxAttempts:    2,
+		ReconnectDelay: time.Millisecond,
+		PollInterval:   time.Hour,
+		OnError: func(err error) {
+			select {
+			case errors <- err:
+			default:
+			}
+		},
+	})
+
+	result := make(chan error, 1)
+	go func() { result <- realtime.Run(ctx) }()
+
+	notification := <-realtime.Notifications()
+	assert.Equal(t, "1538558571001", notification.ID, "should deliver polled notification")
+
+	cancel()
+	for range realtime.Notifications() {
+	}
+
+	assert.Equal(t, context.Canceled, <-result, "should stop on context cancellation")
+
+	reported := []error{}
+	for len(errors) > 0 {
+		reported = append(reported, <-errors)
+	}
+
+	assert.Contains(t, reported, ErrRealtimeUnavailable, "should report fallback to polling")
+}
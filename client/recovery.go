@@ -0,0 +1,544 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/fees"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// RecoveryTx is fully signed transaction sweeping wallet coins to recovery address,
+// which cannot be mined before its lock time
+type RecoveryTx struct {
+	TxID      string    `json:"txid"`
+	RawTx     string    `json:"rawTx"`
+	LockTime  uint32    `json:"lockTime"`
+	ToAddress string    `json:"toAddress"`
+	Amount    int64     `json:"amount"`
+	Fee       int64     `json:"fee"`
+	Inputs    []string  `json:"inputs"` // Outpoints spent, txid:vout
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RecoveryReport lists vault transactions invalidated by changes of wallet coins
+type RecoveryReport struct {
+	Stale     []*RecoveryTx `json:"stale"`     // Transactions spending coins that are no longer unspent
+	Uncovered []string      `json:"uncovered"` // Wallet coins not swept by any valid transaction
+}
+
+// OK reports whether vault still sweeps every wallet coin
+func (r *RecoveryReport) OK() bool {
+	return len(r.Stale) == 0 && len(r.Uncovered) == 0
+}
+
+// RecoveryStore persists recovery transactions
+type RecoveryStore interface {
+	Load() ([]*RecoveryTx, error)
+	Save(txs []*RecoveryTx) error
+}
+
+// MemoryRecoveryStore keeps recovery transactions in memory, useful for tests
+type MemoryRecoveryStore struct {
+	mutex sync.Mutex
+	txs   []*RecoveryTx
+}
+
+// Load returns stored transactions
+func (s *MemoryRecoveryStore) Load() ([]*RecoveryTx, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*RecoveryTx{}, s.txs...), nil
+}
+
+// Save stores transactions
+func (s *MemoryRecoveryStore) Save(txs []*RecoveryTx) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.txs = append([]*RecoveryTx{}, txs...)
+	return nil
+}
+
+// FileRecoveryStore keeps recovery transactions in file encrypted with passphrase,
+// missing file means empty vault
+type FileRecoveryStore struct {
+	Path       string
+	Passphrase string
+}
+
+// Load decrypts transactions from file
+func (s *FileRecoveryStore) Load() ([]*RecoveryTx, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return []*RecoveryTx{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := utils.Decrypt(data, s.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := []*RecoveryTx{}
+	if err := json.Unmarshal(decrypted, &txs); err != nil {
+		return nil, err
+	}
+
+	return txs, nil
+}
+
+// Save encrypts transactions and atomically replaces file
+func (s *FileRecoveryStore) Save(txs []*RecoveryTx) error {
+	data, err := json.Marshal(txs)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := utils.Encrypt(data, s.Passphrase)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.Path, encrypted)
+}
+
+// RecoveryVault manages recovery transactions kept in RecoveryStore
+type RecoveryVault struct {
+	mutex sync.Mutex
+	store RecoveryStore
+}
+
+// NewRecoveryVault creates vault backed by store, defaults to MemoryRecoveryStore
+func NewRecoveryVault(store RecoveryStore) *RecoveryVault {
+	if store == nil {
+		store = &MemoryRecoveryStore{}
+	}
+
+	return &RecoveryVault{store: store}
+}
+
+// Add stores transaction, replacing stored one with the same ID
+func (v *RecoveryVault) Add(tx *RecoveryTx) error {
+	return v.update(func(txs map[string]*RecoveryTx) error {
+		txs[tx.TxID] = tx
+		return nil
+	})
+}
+
+// Remove deletes transaction from vault
+func (v *RecoveryVault) Remove(txID string) error {
+	return v.update(func(txs map[string]*RecoveryTx) error {
+		if _, ok := txs[txID]; !ok {
+			return fmt.Errorf("Recovery transaction %s not found", txID)
+		}
+
+		delete(txs, txID)
+		return nil
+	})
+}
+
+// List returns transactions ordered by lock time
+func (v *RecoveryVault) List() ([]*RecoveryTx, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	txs, err := v.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(txs, func(i, j int) bool {
+		if txs[i].LockTime != txs[j].LockTime {
+			return txs[i].LockTime < txs[j].LockTime
+		}
+
+		return txs[i].TxID < txs[j].TxID
+	})
+
+	return txs, nil
+}
+
+// Check compares vault with current wallet coins, transactions spending coins missing
+// from the set can never be mined and coins received later are not swept at all
+func (v *RecoveryVault) Check(coins []*models.TxInput) (*RecoveryReport, error) {
+	txs, err := v.List()
+	if err != nil {
+		return nil, err
+	}
+
+	unspent := map[string]bool{}
+	for _, coin := range coins {
+		unspent[coin.Outpoint()] = true
+	}
+
+	report := &RecoveryReport{Stale: []*RecoveryTx{}, Uncovered: []string{}}
+	covered := map[string]bool{}
+	for _, tx := range txs {
+		stale := false
+		for _, outpoint := range tx.Inputs {
+			if !unspent[outpoint] {
+				stale = true
+			}
+		}
+
+		if stale {
+			report.Stale = append(report.Stale, tx)
+			continue
+		}
+
+		for _, outpoint := range tx.Inputs {
+			covered[outpoint] = true
+		}
+	}
+
+	for _, coin := range coins {
+		if !covered[coin.Outpoint()] {
+			report.Uncovered = append(report.Uncovered, coin.Outpoint())
+		}
+	}
+
+	return report, nil
+}
+
+func (v *RecoveryVault) update(change func(txs map[string]*RecoveryTx) error) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	list, err := v.store.Load()
+	if err != nil {
+		return err
+	}
+
+	txs := map[string]*RecoveryTx{}
+	for _, tx := range list {
+		txs[tx.TxID] = tx
+	}
+
+	if err := change(txs); err != nil {
+		return err
+	}
+
+	list = []*RecoveryTx{}
+	for _, tx := range txs {
+		list = append(list, tx)
+	}
+
+	return v.store.Save(list)
+}
+
+// RecoveryOptions configures recovery transaction sweeping all wallet coins
+type RecoveryOptions struct {
+	ToAddress string // Recovery address outside of wallet
+	LockTime  uint32 // Block height or unix timestamp before which transaction cannot be mined
+	FeeLevel  string // Fee level, defaults to normal unless FeePerKb is set
+	FeePerKb  uint64 // Custom fee rate in satoshis per kilobyte
+}
+
+// RecoveryRequest carries recovery proposal with wallet it spends from between
+// copayers signing it offline
+type RecoveryRequest struct {
+	Wallet   *models.Wallet     `json:"wallet"`
+	Proposal *models.TxProposal `json:"proposal"`
+}
+
+// BuildRecoveryProposal builds timelocked proposal sweeping all spendable coins to recovery
+// address locally, without storing it on server, so copayers can sign it offline
+func (c *Client) BuildRecoveryProposal(opts RecoveryOptions) (*RecoveryRequest, error) {
+	if c.cfg.Coin == config.CoinBCH {
+		return nil, errors.New("Recovery transactions of BCH wallets are not supported")
+	}
+
+	if opts.LockTime == 0 {
+		return nil, errors.New("Recovery transaction lock time not specified")
+	}
+
+	if _, err := models.DecodeAddress(opts.ToAddress, c.cfg.Coin, c.cfg.NetParams()); err != nil {
+		return nil, fmt.Errorf("Invalid recovery address: %s", opts.ToAddress)
+	}
+
+	// Copayer keys are reported only with extended info
+	status, err := c.GetStatus(true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.Wallet == nil {
+		return nil, errors.New("Wallet not found")
+	}
+
+	coins, err := c.SelectCoins(CoinFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(coins) == 0 {
+		return nil, ErrNothingToSend
+	}
+
+	feePerKb := opts.FeePerKb
+	if feePerKb == 0 {
+		if feePerKb, err = c.FeePerKb(opts.FeeLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	sizes, err := fees.NewEstimator(status.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, coin := range coins {
+		total += coin.Satoshis
+	}
+
+	outputSizes, err := fees.OutputSizes(models.NewTxOutputSingle(0, opts.ToAddress), c.cfg.Coin, c.cfg.NetParams())
+	if err != nil {
+		return nil, err
+	}
+
+	fee := fees.Fee(sizes.PaymentVSize(len(coins), outputSizes, false), feePerKb)
+	if total-fee < dustLimit {
+		return nil, fmt.Errorf("Coins worth %d cannot pay fee %d", total, fee)
+	}
+
+	wallet := status.Wallet
+	creator := c.ownCopayer(wallet)
+	if creator == nil {
+		return nil, errors.New("Client is not wallet copayer")
+	}
+
+	txp := &models.TxProposal{
+		WalletID:           wallet.ID,
+		CreatorID:          creator.ID,
+		CreatorName:        creator.Name,
+		CreatedOn:          uint(time.Now().Unix()),
+		Coin:               c.cfg.Coin,
+		Network:            c.cfg.Network,
+		WalletM:            int(wallet.M),
+		WalletN:            int(wallet.N),
+		RequiredSignatures: wallet.M,
+		AddressType:        wallet.AddressType,
+		Status:             "pending",
+		FeePerKB:           uint(feePerKb),
+		NoShuffleOutputs:   true,
+		LockTime:           opts.LockTime,
+		Amount:             total - fee,
+		Fee:                fee,
+		OutputOrder:        []int{0},
+		Inputs:             coins,
+		Outputs:            models.NewTxOutputSingle(total-fee, opts.ToAddress),
+		Actions:            []*models.TxAction{},
+	}
+
+	if err := txp.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryRequest{Wallet: wallet, Proposal: txp}, nil
+}
+
+// VerifyRecovery checks that request sweeps coins of client keys to single output with lock time
+// still in the future and verifies signatures already collected in it. Height is current chain
+// height known to signer, required for lock times set as block height.
+func (c *Client) VerifyRecovery(req *RecoveryRequest, height int32) error {
+	if req.Wallet == nil || req.Proposal == nil {
+		return errors.New("Recovery request is incomplete")
+	}
+
+	txp := req.Proposal
+	if err := checkRecoveryProposal(txp); err != nil {
+		return err
+	}
+
+	if !txp.IsTimeLocked() && height <= 0 {
+		return errors.New("Current block height is required to check recovery lock time")
+	}
+
+	// Transaction valid in the next block could be broadcast right away
+	if txp.IsFinal(height+1, time.Now()) {
+		return errors.New("Recovery lock time has already passed")
+	}
+
+	if err := c.checkFrozen(txp.Inputs); err != nil {
+		return err
+	}
+
+	copayer := c.ownCopayer(req.Wallet)
+	if copayer == nil {
+		return errors.New("Client is not wallet copayer")
+	}
+
+	for _, action := range txp.Actions {
+		if action.CopayerID == copayer.ID {
+			return errors.New("Recovery proposal is already signed by client")
+		}
+	}
+
+	if err := c.checkOwnInputs(txp); err != nil {
+		return err
+	}
+
+	return txp.VerifyInputSignatures(req.Wallet, c.cfg.NetParams())
+}
+
+// SignRecovery verifies request with VerifyRecovery and adds signatures of client,
+// it does not contact server and works on air-gapped machines
+func (c *Client) SignRecovery(req *RecoveryRequest, height int32) error {
+	if err := c.VerifyRecovery(req, height); err != nil {
+		return err
+	}
+
+	txp := req.Proposal
+	copayer := c.ownCopayer(req.Wallet)
+	signatures := []string{}
+	for idx, input := range txp.Inputs {
+		privKey, _, err := c.keys.DeriveFromAccount(input.Path)
+		if err != nil {
+			return err
+		}
+
+		signature, err := txp.InputSignature(privKey, c.cfg.NetParams(), idx)
+		if err != nil {
+			return err
+		}
+
+		signatures = append(signatures, utils.ToHex(signature))
+	}
+
+	txp.Actions = append(txp.Actions, &models.TxAction{
+		CreatedOn:   uint(time.Now().Unix()),
+		Type:        models.ActionAccept,
+		CopayerID:   copayer.ID,
+		CopayerName: copayer.Name,
+		XPub:        copayer.XPubKey,
+		Signatures:  signatures,
+	})
+
+	return nil
+}
+
+// FinalizeRecovery assembles recovery transaction from signatures of proposal built by BuildRecoveryProposal
+func (c *Client) FinalizeRecovery(txp *models.TxProposal) (*RecoveryTx, error) {
+	if err := checkRecoveryProposal(txp); err != nil {
+		return nil, err
+	}
+
+	tx, err := txp.Finalize(c.cfg.NetParams())
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := tx.Serialize(buffer); err != nil {
+		return nil, err
+	}
+
+	inputs := []string{}
+	for _, input := range txp.Inputs {
+		inputs = append(inputs, input.Outpoint())
+	}
+
+	return &RecoveryTx{
+		TxID:      tx.TxHash().String(),
+		RawTx:     utils.ToHex(buffer.Bytes()),
+		LockTime:  txp.LockTime,
+		ToAddress: txp.Outputs[0].ToAddress,
+		Amount:    txp.Amount,
+		Fee:       txp.Fee,
+		Inputs:    inputs,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// checkRecoveryProposal fails unless proposal is timelocked and sweeps its coins to single output
+func checkRecoveryProposal(txp *models.TxProposal) error {
+	if txp.LockTime == 0 {
+		return errors.New("Recovery proposal is not timelocked")
+	}
+
+	var total int64
+	for _, input := range txp.Inputs {
+		total += input.Satoshis
+	}
+
+	if len(txp.Outputs) != 1 || txp.Outputs[0].Amount != txp.Amount || total != txp.Amount+txp.Fee {
+		return errors.New("Recovery proposal must sweep coins to single output")
+	}
+
+	return nil
+}
+
+// checkOwnInputs fails unless every input pays to address of client key at input path,
+// alone or among keys of all wallet copayers
+func (c *Client) checkOwnInputs(txp *models.TxProposal) error {
+	for _, input := range txp.Inputs {
+		_, pubKey, err := c.keys.DeriveFromAccount(input.Path)
+		if err != nil {
+			return err
+		}
+
+		own := utils.ToHex(pubKey.SerializeCompressed())
+		checked := *input
+		if len(checked.PublicKeys) == 0 && txp.WalletN <= 1 {
+			checked.PublicKeys = []string{own}
+		}
+
+		found := false
+		for _, key := range checked.PublicKeys {
+			if key == own {
+				found = true
+			}
+		}
+
+		if !found || (txp.WalletN > 1 && len(checked.PublicKeys) != txp.WalletN) {
+			return fmt.Errorf("Input %s does not belong to client key at %s", input.Outpoint(), input.Path)
+		}
+
+		script, err := txp.BuildInputScript(&checked, c.cfg.NetParams())
+		if err != nil {
+			return err
+		}
+
+		if !strings.EqualFold(utils.ToHex(script), input.ScriptPubKey) {
+			return fmt.Errorf("Input %s script does not match its keys", input.Outpoint())
+		}
+	}
+
+	return nil
+}
+
+// CheckRecovery compares vault with current spendable coins of wallet
+func (c *Client) CheckRecovery(vault *RecoveryVault) (*RecoveryReport, error) {
+	coins, err := c.SelectCoins(CoinFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	return vault.Check(coins)
+}
+
+// ownCopayer returns copayer of wallet using account key of client
+func (c *Client) ownCopayer(wallet *models.Wallet) *models.Copayer {
+	xpub := c.keys.AccExtPubKey.String()
+	for _, copayer := range wallet.Copayers {
+		if copayer.XPubKey == xpub {
+			return copayer
+		}
+	}
+
+	return nil
+}
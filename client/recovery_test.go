@@ -0,0 +1,169 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryVault(t *testing.T) {
+	vault := NewRecoveryVault(nil)
+	assert.NoError(t, vault.Add(&RecoveryTx{TxID: "late", LockTime: 700000, Inputs: []string{"a:0"}}))
+	assert.NoError(t, vault.Add(&RecoveryTx{TxID: "early", LockTime: 600000, Inputs: []string{"b:1", "c:0"}}))
+
+	txs, err := vault.List()
+	assert.NoError(t, err)
+	assert.Equal(t, "early", txs[0].TxID, "should order by lock time")
+
+	coins := []*models.TxInput{{TxID: "a", Vout: 0}, {TxID: "b", Vout: 1}, {TxID: "c", Vout: 0}}
+	report, err := vault.Check(coins)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), "should cover every coin")
+
+	report, _ = vault.Check([]*models.TxInput{coins[0], coins[1], {TxID: "d", Vout: 2}})
+	assert.False(t, report.OK())
+	assert.Len(t, report.Stale, 1, "should flag transaction spending spent coin")
+	assert.Equal(t, "early", report.Stale[0].TxID)
+	assert.Equal(t, []string{"b:1", "d:2"}, report.Uncovered, "should flag coins not swept by valid transaction")
+
+	assert.NoError(t, vault.Remove("early"))
+	assert.Error(t, vault.Remove("early"), "should not remove missing transaction")
+}
+
+func TestFileRecoveryStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bws-recovery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "recovery.enc")
+	vault := NewRecoveryVault(&FileRecoveryStore{Path: path, Passphrase: "secret"})
+	txs, err := vault.List()
+	assert.NoError(t, err, "should treat missing file as empty vault")
+	assert.Empty(t, txs)
+
+	assert.NoError(t, vault.Add(&RecoveryTx{TxID: "recovery", RawTx: "0100", Inputs: []string{"a:0"}}))
+	data, _ := ioutil.ReadFile(path)
+	assert.NotContains(t, string(data), "recovery", "should encrypt vault")
+
+	txs, err = NewRecoveryVault(&FileRecoveryStore{Path: path, Passphrase: "secret"}).List()
+	assert.NoError(t, err)
+	assert.Equal(t, "0100", txs[0].RawTx, "should load stored transaction")
+
+	_, err = NewRecoveryVault(&FileRecoveryStore{Path: path, Passphrase: "wrong"}).List()
+	assert.Error(t, err, "should require passphrase")
+}
+
+func TestRecovery(t *testing.T) {
+	routes := routes{}
+	server, client, _ := newRouteServer(t, routes)
+	defer server.Close()
+
+	// Own P2PKH coins
+	_, pubKey, _ := client.keys.DeriveFromAccount("m/0/0")
+	address, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), client.cfg.NetParams())
+	script, _ := txscript.PayToAddrScript(address)
+	utxos := []*models.TxInput{
+		{TxID: "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", Vout: 1, Satoshis: 60000, Path: "m/0/0", ScriptPubKey: utils.ToHex(script)},
+		{TxID: "b53d75d4b45b574d8200c2539b0af761dddc94aa2005047643e2a5a71a695d52", Vout: 0, Satoshis: 40000, Path: "m/0/0", ScriptPubKey: utils.ToHex(script)},
+	}
+
+	routes["/v2/wallets/"] = respondWallet(t, ownWallet(client))
+	routes["/v2/feelevels/"] = respond(200, []*models.FeeLevel{{Level: "normal", FeePerKb: 10000}})
+	routes["/v1/utxos/"] = func(res http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(res).Encode(utxos)
+	}
+
+	opts := RecoveryOptions{ToAddress: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", LockTime: 1900000000}
+	_, err := client.BuildRecoveryProposal(RecoveryOptions{ToAddress: opts.ToAddress})
+	assert.Error(t, err, "should require lock time")
+
+	_, err = client.BuildRecoveryProposal(RecoveryOptions{ToAddress: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", LockTime: opts.LockTime})
+	assert.Error(t, err, "should reject address of other network")
+
+	req, err := client.BuildRecoveryProposal(opts)
+	assert.NoError(t, err, "should build recovery proposal")
+	assert.Equal(t, int64(100000), req.Proposal.Amount+req.Proposal.Fee, "should sweep every coin")
+	assert.Equal(t, uint32(1900000000), req.Proposal.LockTime)
+
+	_, err = client.FinalizeRecovery(req.Proposal)
+	assert.Error(t, err, "should require signatures")
+
+	// Request travels to air-gapped signer as JSON
+	data, _ := json.Marshal(req)
+	signed := &RecoveryRequest{}
+	json.Unmarshal(data, signed)
+	assert.NoError(t, client.SignRecovery(signed, 0), "should sign recovery proposal")
+	assert.Error(t, client.SignRecovery(signed, 0), "should not sign twice")
+
+	recovery, err := client.FinalizeRecovery(signed.Proposal)
+	assert.NoError(t, err, "should finalize recovery transaction")
+	assert.Equal(t, []string{utxos[0].Outpoint(), utxos[1].Outpoint()}, recovery.Inputs)
+	assert.Equal(t, opts.ToAddress, recovery.ToAddress)
+
+	vault := NewRecoveryVault(nil)
+	assert.NoError(t, vault.Add(recovery))
+	report, err := client.CheckRecovery(vault)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), "should cover current coins")
+
+	utxos = utxos[1:]
+	report, err = client.CheckRecovery(vault)
+	assert.NoError(t, err)
+	assert.Len(t, report.Stale, 1, "should detect spent coin")
+	assert.Equal(t, []string{utxos[0].Outpoint()}, report.Uncovered)
+
+	// Signature of other copayer swapped between inputs
+	action := signed.Proposal.Actions[0]
+	action.CopayerID = "other"
+	action.Signatures[0] = action.Signatures[1]
+	signed.Wallet.Copayers = append(signed.Wallet.Copayers, &models.Copayer{ID: "other", XPubKey: action.XPub})
+	assert.Error(t, client.SignRecovery(signed, 0), "should verify collected signatures")
+}
+
+func TestVerifyRecovery(t *testing.T) {
+	routes := routes{}
+	server, client, _ := newRouteServer(t, routes)
+	defer server.Close()
+
+	_, pubKey, _ := client.keys.DeriveFromAccount("m/0/1")
+	address, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), client.cfg.NetParams())
+	script, _ := txscript.PayToAddrScript(address)
+	utxos := []*models.TxInput{{TxID: mockTxInput.TxID, Vout: 1, Satoshis: 60000, Path: "m/0/1", ScriptPubKey: utils.ToHex(script)}}
+	routes["/v2/wallets/"] = respondWallet(t, ownWallet(client))
+	routes["/v2/feelevels/"] = respond(200, []*models.FeeLevel{{Level: "normal", FeePerKb: 10000}})
+	routes["/v1/utxos/"] = respond(200, utxos)
+
+	build := func(lockTime uint32) *RecoveryRequest {
+		req, err := client.BuildRecoveryProposal(RecoveryOptions{ToAddress: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", LockTime: lockTime})
+		assert.NoError(t, err, "should build recovery proposal")
+		return req
+	}
+
+	req := build(800000)
+	assert.Error(t, client.VerifyRecovery(req, 0), "should require height for block height lock time")
+	assert.Error(t, client.VerifyRecovery(req, 800000), "should reject lock time already passed")
+	assert.NoError(t, client.VerifyRecovery(req, 799000), "should accept future lock height")
+
+	assert.Error(t, client.VerifyRecovery(build(1600000000), 0), "should reject lock date already passed")
+
+	req = build(1900000000)
+	req.Proposal.Inputs[0].Path = "m/0/2"
+	assert.Error(t, client.VerifyRecovery(req, 0), "should reject input of other key")
+
+	req = build(1900000000)
+	req.Proposal.Inputs[0].ScriptPubKey = "76a9143874e8eb8a2e018c46721fcdd8e9049f619af35488ac"
+	assert.Error(t, client.VerifyRecovery(req, 0), "should reject input script of other address")
+
+	req = build(1900000000)
+	req.Proposal.Outputs = append(req.Proposal.Outputs, models.NewTxOutput(1000, "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK"))
+	assert.Error(t, client.VerifyRecovery(req, 0), "should require single output")
+}
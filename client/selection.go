@@ -0,0 +1,139 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pavel-main/bws-go/coinselect"
+	"github.com/pavel-main/bws-go/fees"
+	"github.com/pavel-main/bws-go/models"
+)
+
+// SelectionOptions configures local coin selection
+type SelectionOptions struct {
+	Strategy coinselect.Strategy // Selection algorithm, defaults to branch and bound with knapsack fallback
+	Filter   CoinFilter          // Coins considered for selection
+	Sizes    *fees.Estimator     // Wallet size model, loaded from wallet status if not set
+}
+
+// FeePerKb returns fee rate of named fee level, defaults to normal
+func (c *Client) FeePerKb(feeLevel string) (uint64, error) {
+	if feeLevel == "" {
+		feeLevel = defaultFeeLevel
+	}
+
+	levels, err := c.GetFeeLevels()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, level := range levels {
+		if level.Level == feeLevel {
+			return uint64(level.FeePerKb), nil
+		}
+	}
+
+	return 0, fmt.Errorf("Unknown fee level: %s", feeLevel)
+}
+
+// Estimator returns transaction size model of the wallet
+func (c *Client) Estimator() (*fees.Estimator, error) {
+	status, err := c.GetStatus(false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.Wallet == nil {
+		return nil, errors.New("Wallet not found")
+	}
+
+	return fees.NewEstimator(status.Wallet)
+}
+
+// QuoteFees returns expected fees of transaction with given number of inputs and outputs at every fee level
+func (c *Client) QuoteFees(inputs, outputs int) ([]*fees.Quote, error) {
+	estimator, err := c.Estimator()
+	if err != nil {
+		return nil, err
+	}
+
+	levels, err := c.GetFeeLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	return estimator.Quote(levels, inputs, outputs), nil
+}
+
+// SelectInputs chooses coins paying outputs at fee rate of opts locally
+func (c *Client) SelectInputs(opts *TxProposalOptions, selection SelectionOptions) (*coinselect.Result, error) {
+	feePerKb := opts.FeePerKb
+	if feePerKb == 0 {
+		rate, err := c.FeePerKb(opts.FeeLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		feePerKb = rate
+	}
+
+	filter := selection.Filter
+	filter.ExcludeUnconfirmed = filter.ExcludeUnconfirmed || opts.ExcludeUnconfirmedUtxos
+	coins, err := c.SelectCoins(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var target int64
+	for _, output := range opts.Outputs {
+		if output != nil {
+			target += output.Amount
+		}
+	}
+
+	sizes := selection.Sizes
+	if sizes == nil {
+		if sizes, err = c.Estimator(); err != nil {
+			return nil, err
+		}
+	}
+
+	outputSizes, err := fees.OutputSizes(opts.Outputs, c.cfg.Coin, c.cfg.NetParams())
+	if err != nil {
+		return nil, err
+	}
+
+	params := sizes.PaymentParams(target, feePerKb, outputSizes)
+	return coinselect.Select(selection.Strategy, coins, params)
+}
+
+// CreateTxProposalWithSelection selects coins locally and creates proposal spending exactly them
+// with fee calculated by selection
+func (c *Client) CreateTxProposalWithSelection(opts *TxProposalOptions, selection SelectionOptions) (*models.TxProposal, *coinselect.Result, error) {
+	if err := opts.Validate(c.cfg.Coin); err != nil {
+		return nil, nil, err
+	}
+
+	if opts.SendMax || len(opts.Inputs) != 0 || opts.Fee != 0 {
+		return nil, nil, errors.New("Coin selection conflicts with send max, explicit inputs and fee")
+	}
+
+	result, err := c.SelectInputs(opts, selection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selected := *opts
+	selected.Inputs = result.Inputs
+	selected.Fee = result.Fee
+	selected.FeeLevel = ""
+	selected.FeePerKb = 0
+	selected.ExcludeUnconfirmedUtxos = false
+
+	txp, err := c.CreateTxProposalWithOptions(&selected)
+	if err != nil {
+		return nil, result, err
+	}
+
+	return txp, result, nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pavel-main/bws-go/coinselect"
+	"github.com/pavel-main/bws-go/fees"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTxProposalWithSelection(t *testing.T) {
+	utxos := []*models.TxInput{
+		{TxID: "aa", Vout: 0, Satoshis: 500000, Confirmations: 6},
+		{TxID: "bb", Vout: 0, Satoshis: 90000, Confirmations: 3},
+		{TxID: "cc", Vout: 0, Satoshis: 60000, Confirmations: 0},
+	}
+
+	var payload map[string]interface{}
+	server, client, _ := newRouteServer(t, routes{
+		"/v1/utxos/":     respond(200, utxos),
+		"/v2/wallets/":   respond(200, &models.WalletStatus{Wallet: mockWallet}),
+		"/v2/feelevels/": respond(200, []*models.FeeLevel{{Level: "normal", FeePerKb: 2000}, {Level: "economy", FeePerKb: 1000}}),
+		"": func(res http.ResponseWriter, req *http.Request) {
+			json.NewDecoder(req.Body).Decode(&payload)
+			json.NewEncoder(res).Encode(&models.TxProposal{ID: "txp"})
+		},
+	})
+	defer server.Close()
+
+	opts := &TxProposalOptions{
+		Outputs:                 models.NewTxOutputSingle(80000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		FeeLevel:                "economy",
+		ExcludeUnconfirmedUtxos: true,
+	}
+
+	txp, result, err := client.CreateTxProposalWithSelection(opts, SelectionOptions{Strategy: coinselect.StrategyLargestFirst})
+	assert.NoError(t, err, "should create proposal")
+	assert.Equal(t, "txp", txp.ID)
+	assert.Equal(t, []string{"aa:0"}, outpoints(result.Inputs), "should select largest coin")
+
+	inputs := payload["inputs"].([]interface{})
+	assert.Len(t, inputs, 1, "should pass selected inputs")
+	assert.Equal(t, float64(result.Fee), payload["fee"], "should pass selected fee")
+	assert.NotContains(t, payload, "feeLevel", "should not pass fee level")
+	assert.NotContains(t, payload, "excludeUnconfirmedUtxos", "should not conflict with explicit inputs")
+
+	// Unconfirmed coin is excluded, so only the two confirmed coins may combine
+	_, result, err = client.CreateTxProposalWithSelection(opts, SelectionOptions{Strategy: coinselect.StrategyKnapsack})
+	assert.NoError(t, err, "should create proposal")
+	assert.NotContains(t, outpoints(result.Inputs), "cc:0", "should not select unconfirmed coin")
+
+	opts.FeeLevel = "urgent"
+	_, _, err = client.CreateTxProposalWithSelection(opts, SelectionOptions{})
+	assert.Error(t, err, "should fail on unknown fee level")
+
+	_, _, err = client.CreateTxProposalWithSelection(&TxProposalOptions{
+		Outputs: models.NewTxOutputSingle(80000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		Inputs:  utxos[:1],
+	}, SelectionOptions{})
+	assert.Error(t, err, "should reject explicit inputs")
+}
+
+func TestQuoteFees(t *testing.T) {
+	server, client, _ := newRouteServer(t, routes{
+		"/v2/wallets/":   respond(200, &models.WalletStatus{Wallet: &models.Wallet{M: 1, N: 1, AddressType: "P2WPKH"}}),
+		"/v2/feelevels/": respond(200, []*models.FeeLevel{{Level: "normal", FeePerKb: 10000}}),
+	})
+	defer server.Close()
+
+	quotes, err := client.QuoteFees(1, 2)
+	assert.NoError(t, err, "should quote fees")
+	assert.Equal(t, []*fees.Quote{{Level: "normal", FeePerKb: 10000, Fee: 1410}}, quotes, "should quote segwit transaction")
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// ErrNothingToSend is returned when no coins can be swept after paying fees
+var ErrNothingToSend = errors.New("No funds available to send")
+
+// SendMaxOptions configures sweeping all available funds to single address
+type SendMaxOptions struct {
+	ToAddress               string  // Destination address
+	FeeLevel                string  // Named fee level, defaults to normal unless FeePerKb is set
+	FeePerKb                uint64  // Custom fee rate in satoshis per kilobyte
+	Message                 *string // Proposal message
+	ExcludeUnconfirmedUtxos bool    // Only spend confirmed coins
+	Replaceable             bool    // Signal opt-in replace-by-fee (BIP125)
+	TxProposalID            string  // Client-chosen proposal ID
+	DryRun                  bool    // Only estimate proposal without storing it
+	Publish                 bool    // Publish created proposal, ignored for dry runs
+}
+
+// SendMaxResult describes swept coins and coins left behind
+type SendMaxResult struct {
+	Proposal           *models.TxProposal `json:"proposal"`
+	Info               *models.MaxInfo    `json:"info"`
+	UtxosBelowFee      uint               `json:"utxosBelowFee"`      // Coins worth less than fee to spend them
+	AmountBelowFee     int64              `json:"amountBelowFee"`     // Total of coins worth less than fee
+	UtxosAboveMaxSize  uint               `json:"utxosAboveMaxSize"`  // Coins not fitting into max transaction size
+	AmountAboveMaxSize int64              `json:"amountAboveMaxSize"` // Total of coins not fitting into max size
+}
+
+// SendMax creates proposal spending exactly inputs reported by send max info,
+// so coins received meanwhile are not swept and no change (dust) is left
+func (c *Client) SendMax(opts SendMaxOptions) (*SendMaxResult, error) {
+	params := map[string]string{
+		"returnInputs":            "true",
+		"excludeUnconfirmedUtxos": utils.BoolToString(opts.ExcludeUnconfirmedUtxos),
+	}
+
+	if opts.FeePerKb != 0 {
+		params["feePerKb"] = strconv.FormatUint(opts.FeePerKb, 10)
+	} else if opts.FeeLevel != "" {
+		params["feeLevel"] = opts.FeeLevel
+	}
+
+	info, err := c.getMaxInfo(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SendMaxResult{
+		Info:               info,
+		UtxosBelowFee:      info.UtxosBelowFee,
+		AmountBelowFee:     info.AmountBelowFee,
+		UtxosAboveMaxSize:  info.UtxosAboveMaxSize,
+		AmountAboveMaxSize: info.AmountAboveMaxSize,
+	}
+
+	if info.Amount <= 0 || len(info.Inputs) == 0 {
+		return result, ErrNothingToSend
+	}
+
+	txp, err := c.CreateTxProposalWithOptions(&TxProposalOptions{
+		Outputs:          models.NewTxOutputSingle(info.Amount, opts.ToAddress),
+		Fee:              info.Fee,
+		Inputs:           info.Inputs,
+		Message:          opts.Message,
+		NoShuffleOutputs: true,
+		Replaceable:      opts.Replaceable,
+		TxProposalID:     opts.TxProposalID,
+		DryRun:           opts.DryRun,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	if err := checkMaxProposal(txp, info); err != nil {
+		return result, c.discardTxProposal(txp, opts.DryRun, err)
+	}
+
+	if opts.Publish && !opts.DryRun {
+		if txp, err = c.PublishTxProposal(txp); err != nil {
+			return result, err
+		}
+	}
+
+	result.Proposal = txp
+	return result, nil
+}
+
+// checkMaxProposal ensures server spent exactly the expected inputs
+func checkMaxProposal(txp *models.TxProposal, info *models.MaxInfo) error {
+	if txp.Amount != info.Amount || txp.Fee != info.Fee {
+		return fmt.Errorf("Proposal amount %d and fee %d do not match send max info", txp.Amount, txp.Fee)
+	}
+
+	if len(txp.Inputs) == 0 {
+		return nil // Dry runs may omit inputs
+	}
+
+	if !sameInputs(txp.Inputs, info.Inputs) {
+		return errors.New("Proposal inputs do not match send max info")
+	}
+
+	return nil
+}
+
+// sameInputs reports whether both lists spend the same outpoints
+func sameInputs(a, b []*models.TxInput) bool {
+	expected := map[string]bool{}
+	for _, input := range b {
+		expected[input.Outpoint()] = true
+	}
+
+	if len(a) != len(expected) {
+		return false
+	}
+
+	for _, input := range a {
+		if !expected[input.Outpoint()] {
+			return false
+		}
+	}
+
+	return true
+}
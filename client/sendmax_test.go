@@ -0,0 +1,94 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var mockMaxInfo = &models.MaxInfo{
+	Amount:            14110166,
+	Fee:               246,
+	FeePerKB:          1000,
+	Inputs:            []*models.TxInput{mockTxInput},
+	UtxosBelowFee:     2,
+	AmountBelowFee:    300,
+	UtxosAboveMaxSize: 1,
+}
+
+// sendMaxRoutes answer send max info, proposal creation and publishing
+func sendMaxRoutes(info *models.MaxInfo, txp *models.TxProposal) routes {
+	return routes{
+		"/v1/sendmaxinfo/": respond(200, info),
+		"/v1/txproposals/*/publish/": func(res http.ResponseWriter, req *http.Request) {
+			published := *txp
+			published.Status = "pending"
+			json.NewEncoder(res).Encode(&published)
+		},
+		"": respond(200, txp),
+	}
+}
+
+func TestSendMax(t *testing.T) {
+	txp := &models.TxProposal{
+		ID:          "txp",
+		Amount:      mockMaxInfo.Amount,
+		Fee:         mockMaxInfo.Fee,
+		Status:      "temporary",
+		WalletM:     1,
+		OutputOrder: []int{0},
+		Inputs:      []*models.TxInput{mockTxInput},
+		Outputs:     models.NewTxOutputSingle(mockMaxInfo.Amount, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+	}
+
+	server, client, log := newRouteServer(t, sendMaxRoutes(mockMaxInfo, txp))
+	defer server.Close()
+
+	result, err := client.SendMax(SendMaxOptions{ToAddress: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", FeePerKb: 1000, Publish: true})
+	assert.NoError(t, err, "should sweep wallet")
+	assert.Equal(t, "pending", result.Proposal.Status, "should publish proposal")
+	assert.Equal(t, uint(2), result.UtxosBelowFee, "should report coins below fee")
+	assert.Equal(t, int64(300), result.AmountBelowFee, "should report amount below fee")
+	assert.Equal(t, uint(1), result.UtxosAboveMaxSize, "should report coins above max size")
+
+	assert.Len(t, log.Requests(), 3, "should get info, create and publish proposal")
+	assert.Equal(t, "true", log.Requests()[0].Query.Get("returnInputs"), "should request inputs")
+	assert.Equal(t, "1000", log.Requests()[0].Query.Get("feePerKb"), "should pass fee rate")
+}
+
+func TestSendMaxMismatch(t *testing.T) {
+	// Server selected other coins than reported
+	other := *mockTxInput
+	other.Vout = 2
+	txp := &models.TxProposal{
+		ID:     "txp",
+		Amount: mockMaxInfo.Amount,
+		Fee:    mockMaxInfo.Fee,
+		Inputs: []*models.TxInput{&other},
+	}
+
+	server, client, log := newRouteServer(t, sendMaxRoutes(mockMaxInfo, txp))
+	defer server.Close()
+
+	_, err := client.SendMax(SendMaxOptions{ToAddress: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", Publish: true})
+	assert.Error(t, err, "should reject proposal with unexpected inputs")
+	assert.Len(t, log.Requests(), 3, "should not publish proposal")
+	assert.Equal(t, "DELETE /v1/txproposals/txp", log.Calls()[2], "should remove mismatching proposal")
+
+	_, err = client.SendMax(SendMaxOptions{ToAddress: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", DryRun: true})
+	assert.Error(t, err, "should reject dry run with unexpected inputs")
+	assert.Len(t, log.Requests(), 5, "should not remove proposal of dry run")
+}
+
+func TestSendMaxNothingToSend(t *testing.T) {
+	server, client, log := newRouteServer(t, sendMaxRoutes(&models.MaxInfo{UtxosBelowFee: 3, AmountBelowFee: 450}, nil))
+	defer server.Close()
+
+	result, err := client.SendMax(SendMaxOptions{ToAddress: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"})
+	assert.Equal(t, ErrNothingToSend, err, "should not create empty proposal")
+	assert.Equal(t, uint(3), result.UtxosBelowFee, "should report dust")
+	assert.Len(t, log.Requests(), 1, "should only request info")
+}
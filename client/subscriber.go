@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pavel-main/bws-go/models"
+)
+
+const defaultPollInterval = 10 * time.Second
+
+// CursorStore persists ID of the last delivered notification
+type CursorStore interface {
+	Load() (string, error)
+	Save(notificationID string) error
+}
+
+// MemoryCursorStore keeps cursor in memory, useful for tests and short-lived processes
+type MemoryCursorStore struct {
+	mutex sync.Mutex
+	id    string
+}
+
+// Load returns stored cursor
+func (s *MemoryCursorStore) Load() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.id, nil
+}
+
+// Save stores cursor
+func (s *MemoryCursorStore) Save(notificationID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.id = notificationID
+	return nil
+}
+
+// FileCursorStore keeps cursor in a file, missing file means empty cursor
+type FileCursorStore struct {
+	Path string
+}
+
+// Load reads cursor from file
+func (s *FileCursorStore) Load() (string, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Save atomically replaces cursor file
+func (s *FileCursorStore) Save(notificationID string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.WriteString(notificationID); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// SubscriberOptions configures notification polling
+type SubscriberOptions struct {
+	Interval   time.Duration // Delay between polls, defaults to 10 seconds
+	TimeSpan   uint64        // Seconds to look back when there is no stored cursor
+	IncludeOwn bool          // Deliver notifications caused by this copayer
+	Buffer     int           // Channel capacity, 0 means every notification waits for consumer
+	Store      CursorStore   // Cursor persistence, defaults to MemoryCursorStore
+	OnError    func(error)   // Called on failed polls, which are retried on the next tick
+}
+
+// Subscriber polls notifications and delivers them on a channel
+type Subscriber struct {
+	client        *Client
+	opts          SubscriberOptions
+	notifications chan *models.Notification
+}
+
+// NewSubscriber creates notification subscriber, call Run to start polling
+func (c *Client) NewSubscriber(opts SubscriberOptions) *Subscriber {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultPollInterval
+	}
+
+	if opts.Store == nil {
+		opts.Store = &MemoryCursorStore{}
+	}
+
+	s := new(Subscriber)
+	s.client = c
+	s.opts = opts
+	s.notifications = make(chan *models.Notification, opts.Buffer)
+	return s
+}
+
+// Notifications returns channel closed when Run returns
+func (s *Subscriber) Notifications() <-chan *models.Notification {
+	return s.notifications
+}
+
+// Run polls notifications until context is cancelled or cursor store fails.
+// Cursor is saved once notification is accepted by the channel, so restarted
+// subscriber continues right after the last delivered notification.
+func (s *Subscriber) Run(ctx context.Context) error {
+	defer close(s.notifications)
+
+	cursor, err := s.opts.Store.Load()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		cursor, err = s.poll(ctx, cursor)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches notifications after cursor and delivers them, returns the new cursor
+func (s *Subscriber) poll(ctx context.Context, cursor string) (string, error) {
+	timeSpan := uint64(0)
+	if cursor == "" {
+		timeSpan = s.opts.TimeSpan
+	}
+
+	notifications, err := s.client.GetNotifications(cursor, timeSpan, s.opts.IncludeOwn)
+	if err != nil {
+		if s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+
+		return cursor, nil
+	}
+
+	for _, notification := range notifications {
+		select {
+		case <-ctx.Done():
+			return cursor, ctx.Err()
+		case s.notifications <- notification:
+		}
+
+		cursor = notification.ID
+		if err := s.opts.Store.Save(cursor); err != nil {
+			return cursor, err
+		}
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// notificationsRoute serves notifications newer than requested notificationId
+func notificationsRoute(notifications []*models.Notification) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		cursor := req.URL.Query().Get("notificationId")
+		result := []*models.Notification{}
+		for _, notification := range notifications {
+			if notification.ID > cursor {
+				result = append(result, notification)
+			}
+		}
+
+		json.NewEncoder(res).Encode(result)
+	}
+}
+
+var mockNotifications = []*models.Notification{
+	{ID: "1538558571001", Type: "NewIncomingTx"},
+	{ID: "1538558571002", Type: "NewTxProposal"},
+	{ID: "1538558571003", Type: "TxProposalAcceptedBy"},
+}
+
+func TestSubscriber(t *testing.T) {
+	server, client, log := newRouteServer(t, routes{"/v1/notifications/": notificationsRoute(mockNotifications)})
+	defer server.Close()
+
+	store := &MemoryCursorStore{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := client.NewSubscriber(SubscriberOptions{Interval: 10 * time.Millisecond, Store: store})
+
+	result := make(chan error, 1)
+	go func() { result <- sub.Run(ctx) }()
+
+	received := []string{}
+	for notification := range sub.Notifications() {
+		received = append(received, notification.ID)
+		if len(received) == len(mockNotifications) {
+			// Let subscriber poll again with advanced cursor
+			time.Sleep(30 * time.Millisecond)
+			cancel()
+		}
+	}
+
+	assert.Equal(t, context.Canceled, <-result, "should stop on context cancellation")
+	assert.Equal(t, []string{"1538558571001", "1538558571002", "1538558571003"}, received, "should deliver every notification once")
+
+	cursor, _ := store.Load()
+	assert.Equal(t, "1538558571003", cursor, "should persist last delivered notification")
+	requests := log.Requests()
+	assert.Equal(t, "", requests[0].Query.Get("notificationId"), "should start without cursor")
+	assert.Equal(t, "1538558571003", requests[len(requests)-1].Query.Get("notificationId"), "should poll with advanced cursor")
+}
+
+func TestSubscriberResume(t *testing.T) {
+	server, client, _ := newRouteServer(t, routes{"/v1/notifications/": notificationsRoute(mockNotifications)})
+	defer server.Close()
+
+	store := &MemoryCursorStore{}
+	store.Save("1538558571002")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := client.NewSubscriber(SubscriberOptions{Interval: time.Hour, Store: store})
+	go sub.Run(ctx)
+
+	notification := <-sub.Notifications()
+	assert.Equal(t, "1538558571003", notification.ID, "should resume after stored cursor")
+}
+
+func TestSubscriberBackpressure(t *testing.T) {
+	server, client, _ := newRouteServer(t, routes{"/v1/notifications/": notificationsRoute(mockNotifications)})
+	defer server.Close()
+
+	store := &MemoryCursorStore{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := client.NewSubscriber(SubscriberOptions{Interval: time.Hour, Store: store})
+
+	result := make(chan error, 1)
+	go func() { result <- sub.Run(ctx) }()
+
+	// Consume only one notification and stop while subscriber is blocked
+	notification := <-sub.Notifications()
+	assert.Equal(t, "1538558571001", notification.ID, "should deliver first notification")
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	assert.Equal(t, context.Canceled, <-result, "should stop on context cancellation")
+
+	cursor, _ := store.Load()
+	assert.Equal(t, "1538558571001", cursor, "should not advance cursor past undelivered notifications")
+}
+
+func TestSubscriberErrors(t *testing.T) {
+	server, client := newClientServer(t, http.StatusInternalServerError, nil)
+	defer server.Close()
+
+	errors := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub := client.NewSubscriber(SubscriberOptions{
+		Interval: 5 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errors <- err:
+			default:
+			}
+		},
+	})
+
+	result := make(chan error, 1)
+	go func() { result <- sub.Run(ctx) }()
+
+	assert.Error(t, <-errors, "should report failed poll")
+	assert.Error(t, <-errors, "should keep polling after failure")
+	cancel()
+	assert.Equal(t, context.Canceled, <-result, "should stop on context cancellation")
+}
+
+func TestFileCursorStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bws-cursor")
+	assert.NoError(t, err, "should create temporary directory")
+	defer os.RemoveAll(dir)
+
+	store := &FileCursorStore{Path: filepath.Join(dir, "cursor")}
+	cursor, err := store.Load()
+	assert.NoError(t, err, "should load missing cursor")
+	assert.Equal(t, "", cursor, "should return empty cursor")
+
+	assert.NoError(t, store.Save("1538558571003"), "should save cursor")
+	cursor, err = store.Load()
+	assert.NoError(t, err, "should load cursor")
+	assert.Equal(t, "1538558571003", cursor, "cursors should match")
+
+	store = &FileCursorStore{Path: filepath.Join(dir, "missing", "cursor")}
+	assert.Error(t, store.Save("1"), "should fail on missing directory")
+}
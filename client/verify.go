@@ -0,0 +1,72 @@
+package client
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// ErrWalletKeyUnknown is returned when copayers of wallet created by someone else cannot be verified
+var ErrWalletKeyUnknown = errors.New("Wallet public key unknown, set it with SetWalletPubKey")
+
+// SetWalletPubKey sets hex-encoded public key of wallet invitation secret, which copayers are verified against.
+// It is known to clients that created or joined wallet, others must set it.
+func (c *Client) SetWalletPubKey(key string) error {
+	bytes, err := utils.ToBytes(key)
+	if err != nil {
+		return err
+	}
+
+	pubKey, err := btcec.ParsePubKey(bytes, btcec.S256())
+	if err != nil {
+		return err
+	}
+
+	c.walletKey = pubKey
+	return nil
+}
+
+// WalletPubKey returns hex-encoded public key of joined wallet invitation, empty if not known
+func (c *Client) WalletPubKey() string {
+	if c.walletKey == nil {
+		return ""
+	}
+
+	return utils.ToHex(c.walletKey.SerializeCompressed())
+}
+
+// walletPubKey returns wallet public key trusted by client, never the one reported by server
+func (c *Client) walletPubKey(wallet *models.Wallet) (*btcec.PublicKey, error) {
+	if c.walletKey != nil {
+		return c.walletKey, nil
+	}
+
+	// Wallets created by client are shared with secret of its root key
+	if wallet.PubKey == utils.ToHex(c.keys.RootPubKey.SerializeCompressed()) {
+		return c.keys.RootPubKey, nil
+	}
+
+	return nil, ErrWalletKeyUnknown
+}
+
+// VerifyTxProposal checks that wallet copayers were invited with wallet key known to client, proposal was
+// created by one of them and signatures of accepting copayers are valid, detecting proposals tampered by server
+func (c *Client) VerifyTxProposal(txp *models.TxProposal) error {
+	status, err := c.GetStatus(true, false)
+	if err != nil {
+		return err
+	}
+
+	if status.Wallet == nil {
+		return errors.New("Wallet not found")
+	}
+
+	walletPubKey, err := c.walletPubKey(status.Wallet)
+	if err != nil {
+		return err
+	}
+
+	return txp.Verify(status.Wallet, walletPubKey, c.cfg.NetParams())
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// ownWallet returns wallet created by client having it as its only copayer
+func ownWallet(client *Client) *models.Wallet {
+	wallet := *mockWallet
+	wallet.PubKey = utils.ToHex(client.keys.RootPubKey.SerializeCompressed())
+	signature, _ := utils.SignMessage(client.copayerHash("me"), client.keys.RootPrvKey)
+	wallet.Copayers = []*models.Copayer{{
+		ID:            "me",
+		Name:          "me",
+		XPubKey:       client.keys.AccExtPubKey.String(),
+		RequestPubKey: utils.ToHex(client.keys.ReqPubKey.SerializeCompressed()),
+		Signature:     utils.ToHex(signature),
+	}}
+
+	return &wallet
+}
+
+// ownProposal returns copy of proposal created and signed by client
+func ownProposal(t *testing.T, client *Client, txp *models.TxProposal) *models.TxProposal {
+	own := *txp
+	own.CreatorID = "me"
+	signature, err := own.ProposalSignature(client.keys.ReqPrvKey, client.cfg.NetParams())
+	assert.NoError(t, err, "should sign proposal")
+	own.CreatorSignature = utils.ToHex(signature)
+	return &own
+}
+
+// respondWallet responds with status of wallet, which has copayer keys only when extended info is requested
+func respondWallet(t *testing.T, wallet *models.Wallet) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "1", req.URL.Query().Get("includeExtendedInfo"), "should request copayer keys")
+		respond(http.StatusOK, &models.WalletStatus{Wallet: wallet})(res, req)
+	}
+}
+
+// newVerifiedServer responds with wallet of client to status requests and with expected to others
+func newVerifiedServer(t *testing.T, status int, expected interface{}) (*httptest.Server, *Client) {
+	routes := routes{"": respond(status, expected)}
+	server, client, _ := newRouteServer(t, routes)
+	routes["/v2/wallets/"] = respondWallet(t, ownWallet(client))
+	return server, client
+}
+
+func TestVerifyTxProposal(t *testing.T) {
+	server, client := newVerifiedServer(t, http.StatusOK, nil)
+	defer server.Close()
+
+	txp := ownProposal(t, client, mockTxProposal)
+	assert.NoError(t, client.VerifyTxProposal(txp), "should verify own proposal")
+
+	txp.Amount++
+	assert.Error(t, client.VerifyTxProposal(txp), "should detect tampered proposal")
+
+	_, err := client.SignTxProposal(txp)
+	assert.Error(t, err, "should not sign tampered proposal")
+
+	txp = ownProposal(t, client, mockTxProposal)
+	txp.CreatorID = "other"
+	assert.Error(t, client.VerifyTxProposal(txp), "should reject proposal of unknown creator")
+}
+
+func TestVerifyTxProposalWitness(t *testing.T) {
+	routes := routes{}
+	server, client, _ := newRouteServer(t, routes)
+	defer server.Close()
+
+	wallet := ownWallet(client)
+	wallet.AddressType = models.AddressTypeP2WPKH
+	routes["/v2/wallets/"] = respondWallet(t, wallet)
+
+	private, public, _ := client.keys.DeriveFromAccount("m/0/2")
+	address, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(public.SerializeCompressed()), client.cfg.NetParams())
+	script, _ := txscript.PayToAddrScript(address)
+
+	txp := ownProposal(t, client, mockTxProposal)
+	txp.AddressType = models.AddressTypeP2WPKH
+	txp.Inputs = []*models.TxInput{{TxID: mockTxInput.TxID, Vout: 1, Path: "m/0/2", Satoshis: 14110412, ScriptPubKey: utils.ToHex(script)}}
+	txp = ownProposal(t, client, txp)
+
+	signature, err := txp.InputSignature(private, client.cfg.NetParams(), 0)
+	assert.NoError(t, err, "should sign witness input")
+	txp.Actions = []*models.TxAction{{Type: models.ActionAccept, CopayerID: "me", XPub: client.keys.AccExtPubKey.String(), Signatures: []string{utils.ToHex(signature)}}}
+	assert.NoError(t, client.VerifyTxProposal(txp), "should verify witness signature")
+
+	_, err = txp.Finalize(client.cfg.NetParams())
+	assert.NoError(t, err, "should finalize witness transaction")
+
+	txp.Inputs[0].Satoshis--
+	txp = ownProposal(t, client, txp)
+	assert.Error(t, client.VerifyTxProposal(txp), "should reject signature of other input amount")
+}
+
+func TestVerifyTxProposalWalletKey(t *testing.T) {
+	routes := routes{}
+	server, client, _ := newRouteServer(t, routes)
+	defer server.Close()
+
+	// Wallet created by other copayer
+	invitation, _ := btcec.NewPrivateKey(btcec.S256())
+	wallet := ownWallet(client)
+	wallet.PubKey = utils.ToHex(invitation.PubKey().SerializeCompressed())
+	signature, _ := utils.SignMessage(client.copayerHash("me"), invitation)
+	wallet.Copayers[0].Signature = utils.ToHex(signature)
+	routes["/v2/wallets/"] = respondWallet(t, wallet)
+
+	txp := ownProposal(t, client, mockTxProposal)
+	assert.Equal(t, ErrWalletKeyUnknown, client.VerifyTxProposal(txp), "should not trust wallet key reported by server")
+
+	other, _ := btcec.NewPrivateKey(btcec.S256())
+	assert.NoError(t, client.SetWalletPubKey(utils.ToHex(other.PubKey().SerializeCompressed())))
+	assert.Error(t, client.VerifyTxProposal(txp), "should reject copayers invited to other wallet")
+
+	assert.Error(t, client.SetWalletPubKey("zz"), "should reject invalid key")
+	assert.NoError(t, client.SetWalletPubKey(wallet.PubKey))
+	assert.Equal(t, wallet.PubKey, client.WalletPubKey())
+	assert.NoError(t, client.VerifyTxProposal(txp), "should verify copayers with known wallet key")
+}
@@ -0,0 +1,1487 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pavel-main/bws-go/bip21"
+	bws "github.com/pavel-main/bws-go/client"
+	"github.com/pavel-main/bws-go/coinselect"
+	"github.com/pavel-main/bws-go/credentials"
+	"github.com/pavel-main/bws-go/decoder"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+var commands = []*Command{
+	{Name: "create", Usage: "[-copayer name] [-single] [-mnemonic words] <wallet name> <m> <n>", Summary: "create new wallet and join it", Run: runCreate},
+	{Name: "join", Usage: "[-mnemonic words] <copayer name> <secret>", Summary: "join existing wallet by invitation secret", Run: runJoin},
+	{Name: "status", Usage: "", Summary: "show wallet status", Run: runStatus},
+	{Name: "balance", Usage: "", Summary: "show wallet balance", Run: runBalance},
+	{Name: "address", Usage: "", Summary: "create new receiving address", Run: runAddress},
+	{Name: "addresses", Usage: "[-limit n] [-reverse]", Summary: "list generated addresses", Run: runAddresses},
+	{Name: "utxos", Usage: "[-confirmed-only] [-locked] [address...]", Summary: "list spendable coins", Run: runUtxos},
+	{Name: "freeze", Usage: "[list | add <txid:vout> [note] | note <txid:vout> <note> | remove <txid:vout> | clear]", Summary: "manage coins that must never be spent", Run: runFreeze},
+	{Name: "recovery", Usage: "[list | build [-fee level | -fee-per-kb rate] <address> <height | date> <file> | sign [-height n] [-yes] <file> | store <file> | check | remove <txid>]", Summary: "manage pre-signed timelocked recovery transactions", Run: runRecovery},
+	{Name: "fees", Usage: "[-inputs n] [-outputs n]", Summary: "quote transaction fees at every fee level", Run: runFees},
+	{Name: "send", Usage: "[-fee level | -fee-per-kb rate] [-message text] [-rbf] [-confirmed-only] [-id id] [-coins list] [-select strategy] [-data hex] [-locktime height] [-dry-run] <address> <amount>", Summary: "create and publish transaction proposal", Run: runSend},
+	{Name: "pay", Usage: "[-fee level | -fee-per-kb rate] [-dry-run] <uri>", Summary: "create and publish proposal paying BIP21 URI", Run: runPay},
+	{Name: "uri", Usage: "[-amount amount] [-label text] [-message text] [-r url] [address]", Summary: "build BIP21 payment URI", Run: runURI},
+	{Name: "sweep", Usage: "[-fee level | -fee-per-kb rate] [-confirmed-only] [-dry-run] <address>", Summary: "send all available funds", Run: runSweep},
+	{Name: "txproposals", Usage: "", Summary: "list pending transaction proposals", Run: runTxProposals},
+	{Name: "sign", Usage: "<proposal id>", Summary: "sign transaction proposal", Run: runSign},
+	{Name: "reject", Usage: "<proposal id> [reason]", Summary: "reject transaction proposal", Run: runReject},
+	{Name: "bump", Usage: "[-fee level | -fee-per-kb rate] [-sign] [-broadcast] <proposal id>", Summary: "replace stuck transaction paying higher fee", Run: runBump},
+	{Name: "cpfp", Usage: "[-fee level | -fee-per-kb rate] [-dry-run] <txid>", Summary: "accelerate incoming transaction spending its outputs", Run: runCPFP},
+	{Name: "finalize", Usage: "[-broadcast] <proposal id>", Summary: "assemble signed transaction locally", Run: runFinalize},
+	{Name: "decode", Usage: "[-proposal] <raw tx | proposal id>", Summary: "show contents of raw transaction or proposal", Run: runDecode},
+	{Name: "broadcast", Usage: "[-verify] <proposal id>", Summary: "broadcast accepted transaction proposal", Run: runBroadcast},
+	{Name: "history", Usage: "[-skip n] [-limit n] [-all]", Summary: "show transaction history", Run: runHistory},
+	{Name: "notifications", Usage: "[-last id] [-span seconds] [-own] [-follow] [-interval duration]", Summary: "show wallet notifications", Run: runNotifications},
+	{Name: "scan", Usage: "[-copayer-branches]", Summary: "start address scanning", Run: runScan},
+	{Name: "preferences", Usage: "[-email address] [-language code] [-unit name] [-token-addresses list] [-push-platform name -push-token token]", Summary: "show or update copayer preferences", Run: runPreferences},
+	{Name: "fiatrate", Usage: "[-provider name] [-ts unix time] <currency code>", Summary: "show exchange rate", Run: runFiatRate},
+}
+
+func newFlags(app *App, name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(app.Stderr)
+	return flags
+}
+
+// newCredentials derives credentials for client and encrypts them, caller stores them
+// only after server call succeeds, so failed attempt can be retried
+func newCredentials(app *App, mnemonic string) (string, []byte, error) {
+	cfg, err := app.Config()
+	if err != nil {
+		return "", nil, err
+	}
+
+	generated := ""
+	if mnemonic == "" {
+		entropy, err := bip39.NewEntropy(128)
+		if err != nil {
+			return "", nil, err
+		}
+
+		mnemonic, err = bip39.NewMnemonic(entropy)
+		if err != nil {
+			return "", nil, err
+		}
+
+		generated = mnemonic
+	}
+
+	keys, err := credentials.NewFromMnemonic(cfg, mnemonic)
+	if err != nil {
+		return "", nil, err
+	}
+
+	encrypted, err := app.EncryptCredentials(keys)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return generated, encrypted, nil
+}
+
+func runCreate(app *App, args []string) error {
+	flags := newFlags(app, "create")
+	copayer := flags.String("copayer", "copayer", "copayer name")
+	single := flags.Bool("single", false, "use single address")
+	mnemonic := flags.String("mnemonic", "", "import existing mnemonic instead of generating new one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("create", args, 3, "[-copayer name] [-single] [-mnemonic words] <wallet name> <m> <n>"); err != nil {
+		return err
+	}
+
+	m, err := strconv.ParseUint(args[1], 10, 8)
+	if err != nil {
+		return fmt.Errorf("Invalid number of required signatures: %s", args[1])
+	}
+
+	n, err := strconv.ParseUint(args[2], 10, 8)
+	if err != nil || m == 0 || m > n {
+		return fmt.Errorf("Invalid number of copayers: %s", args[2])
+	}
+
+	generated, encrypted, err := newCredentials(app, *mnemonic)
+	if err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	wallet, err := client.CreateWallet(args[0], uint(m), uint(n), *single)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.JoinWallet(*copayer, wallet.Secret); err != nil {
+		return err
+	}
+
+	if err := app.WriteCredentials(encrypted); err != nil {
+		return err
+	}
+
+	result := map[string]string{
+		"walletId": wallet.WalletID,
+		"secret":   wallet.Secret,
+	}
+
+	if generated != "" {
+		result["mnemonic"] = generated
+	}
+
+	return app.Print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "Wallet created:\t%s\n", wallet.WalletID)
+		if n > 1 {
+			fmt.Fprintf(w, "Secret:\t%s\n", wallet.Secret)
+		}
+
+		if generated != "" {
+			fmt.Fprintf(w, "Mnemonic (write it down):\t%s\n", generated)
+		}
+	})
+}
+
+func runJoin(app *App, args []string) error {
+	flags := newFlags(app, "join")
+	mnemonic := flags.String("mnemonic", "", "import existing mnemonic instead of generating new one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("join", args, 2, "[-mnemonic words] <copayer name> <secret>"); err != nil {
+		return err
+	}
+
+	generated, encrypted, err := newCredentials(app, *mnemonic)
+	if err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	joined, err := client.JoinWallet(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	if err := app.WriteCredentials(encrypted); err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{"wallet": joined.Wallet, "walletKey": client.WalletPubKey()}
+	if generated != "" {
+		result["mnemonic"] = generated
+	}
+
+	return app.Print(result, func(w io.Writer) {
+		if joined.Wallet != nil {
+			fmt.Fprintf(w, "Joined wallet:\t%s\n", joined.Wallet.ID)
+			fmt.Fprintf(w, "Status:\t%s\n", joined.Wallet.Status)
+		}
+
+		fmt.Fprintf(w, "Wallet key (pass as -wallet-key):\t%s\n", client.WalletPubKey())
+
+		if generated != "" {
+			fmt.Fprintf(w, "Mnemonic (write it down):\t%s\n", generated)
+		}
+	})
+}
+
+func runStatus(app *App, args []string) error {
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	status, err := client.GetStatus(true, false)
+	if err != nil {
+		return err
+	}
+
+	return app.Print(status, func(w io.Writer) {
+		wallet := status.Wallet
+		if wallet == nil {
+			fmt.Fprintln(w, "Wallet not found")
+			return
+		}
+
+		fmt.Fprintf(w, "Wallet:\t%s\n", wallet.ID)
+		fmt.Fprintf(w, "Status:\t%s\n", wallet.Status)
+		fmt.Fprintf(w, "Copayers:\t%d-of-%d\n", wallet.M, wallet.N)
+		fmt.Fprintf(w, "Coin:\t%s (%s)\n", wallet.Coin, wallet.Network)
+		fmt.Fprintf(w, "Address type:\t%s\n", wallet.AddressType)
+		fmt.Fprintf(w, "Created on:\t%s\n", formatTime(wallet.CreatedOn))
+		for _, copayer := range wallet.Copayers {
+			fmt.Fprintf(w, "Copayer:\t%s (%s)\n", copayer.Name, copayer.ID)
+		}
+
+		if status.Balance != nil {
+			fmt.Fprintf(w, "Balance:\t%s\n", formatAmount(int64(status.Balance.TotalAmount), wallet.Coin))
+			fmt.Fprintf(w, "Available:\t%s\n", formatAmount(int64(status.Balance.AvailableAmount), wallet.Coin))
+		}
+
+		fmt.Fprintf(w, "Pending proposals:\t%d\n", len(status.PendingTxps))
+		for _, txp := range status.PendingTxps {
+			fmt.Fprintf(w, "Proposal:\t%s %s by %s\n", txp.ID, formatAmount(txp.Amount, txp.Coin), txp.CreatorName)
+		}
+	})
+}
+
+func runBalance(app *App, args []string) error {
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	balance, err := client.GetBalance(false)
+	if err != nil {
+		return err
+	}
+
+	coin := app.cfg.Coin
+	return app.Print(balance, func(w io.Writer) {
+		fmt.Fprintf(w, "Total:\t%s\n", formatAmount(int64(balance.TotalAmount), coin))
+		fmt.Fprintf(w, "Confirmed:\t%s\n", formatAmount(int64(balance.TotalConfirmedAmount), coin))
+		fmt.Fprintf(w, "Locked:\t%s\n", formatAmount(int64(balance.LockedAmount), coin))
+		fmt.Fprintf(w, "Available:\t%s\n", formatAmount(int64(balance.AvailableAmount), coin))
+	})
+}
+
+func runAddress(app *App, args []string) error {
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	address, err := client.CreateAddress(false)
+	if err != nil {
+		return err
+	}
+
+	return app.Print(address, func(w io.Writer) {
+		fmt.Fprintln(w, address.Address)
+	})
+}
+
+func runAddresses(app *App, args []string) error {
+	flags := newFlags(app, "addresses")
+	limit := flags.Int("limit", 0, "maximum number of addresses, 0 for all")
+	reverse := flags.Bool("reverse", false, "list newest addresses first")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	addresses, err := client.GetMainAddresses(*limit, *reverse)
+	if err != nil {
+		return err
+	}
+
+	return app.Print(addresses, func(w io.Writer) {
+		fmt.Fprintln(w, "ADDRESS\tPATH\tCREATED")
+		for _, address := range addresses {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", address.Address, address.Path, formatTime(address.CreatedOn))
+		}
+	})
+}
+
+func runUtxos(app *App, args []string) error {
+	flags := newFlags(app, "utxos")
+	confirmed := flags.Bool("confirmed-only", false, "exclude unconfirmed coins")
+	locked := flags.Bool("locked", false, "include coins locked by pending proposals")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	coins, err := client.SelectCoins(bws.CoinFilter{
+		Addresses:          flags.Args(),
+		ExcludeUnconfirmed: *confirmed,
+		IncludeLocked:      *locked,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return app.Print(coins, func(w io.Writer) {
+		fmt.Fprintln(w, "OUTPOINT\tADDRESS\tAMOUNT\tCONFIRMATIONS\tLOCKED")
+		for _, coin := range coins {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%t\n", coin.Outpoint(), coin.Address, formatAmount(coin.Satoshis, app.cfg.Coin), coin.Confirmations, coin.Locked)
+		}
+	})
+}
+
+func runFreeze(app *App, args []string) error {
+	list := app.FreezeList()
+	action := "list"
+	if len(args) != 0 {
+		action, args = args[0], args[1:]
+	}
+
+	if action != "list" {
+		if err := os.MkdirAll(filepath.Dir(app.FrozenPath), 0700); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch action {
+	case "list":
+	case "add":
+		if err = requireArgs("freeze add", args, 1, "<txid:vout> [note]"); err == nil {
+			err = list.Freeze(args[0], strings.Join(args[1:], " "))
+		}
+	case "note":
+		if err = requireArgs("freeze note", args, 2, "<txid:vout> <note>"); err == nil {
+			err = list.Annotate(args[0], strings.Join(args[1:], " "))
+		}
+	case "remove":
+		if err = requireArgs("freeze remove", args, 1, "<txid:vout>"); err == nil {
+			err = list.Unfreeze(args[0])
+		}
+	case "clear":
+		err = list.Clear()
+	default:
+		err = fmt.Errorf("Unknown freeze action: %s", action)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	coins, err := list.List()
+	if err != nil {
+		return err
+	}
+
+	return app.Print(coins, func(w io.Writer) {
+		fmt.Fprintln(w, "OUTPOINT\tFROZEN\tNOTE")
+		for _, coin := range coins {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", coin.Outpoint, coin.FrozenAt.Local().Format(time.RFC3339), coin.Note)
+		}
+	})
+}
+
+func runRecovery(app *App, args []string) error {
+	action := "list"
+	if len(args) != 0 {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "list":
+		return printRecovery(app)
+	case "build":
+		return runRecoveryBuild(app, args)
+	case "sign":
+		return runRecoverySign(app, args)
+	case "store":
+		if err := requireArgs("recovery store", args, 1, "<file>"); err != nil {
+			return err
+		}
+
+		return runRecoveryStore(app, args[0])
+	case "check":
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+
+		vault, err := app.RecoveryVault()
+		if err != nil {
+			return err
+		}
+
+		report, err := client.CheckRecovery(vault)
+		if err != nil {
+			return err
+		}
+
+		return app.Print(report, func(w io.Writer) {
+			if report.OK() {
+				fmt.Fprintln(w, "Recovery transactions sweep every wallet coin")
+			}
+
+			for _, tx := range report.Stale {
+				fmt.Fprintf(w, "Stale:\t%s spends coins no longer unspent\n", tx.TxID)
+			}
+
+			for _, outpoint := range report.Uncovered {
+				fmt.Fprintf(w, "Uncovered:\t%s\n", outpoint)
+			}
+		})
+	case "remove":
+		if err := requireArgs("recovery remove", args, 1, "<txid>"); err != nil {
+			return err
+		}
+
+		vault, err := app.RecoveryVault()
+		if err != nil {
+			return err
+		}
+
+		if err := vault.Remove(args[0]); err != nil {
+			return err
+		}
+
+		return printVault(app, vault)
+	default:
+		return fmt.Errorf("Unknown recovery action: %s", action)
+	}
+}
+
+func runRecoveryBuild(app *App, args []string) error {
+	flags := newFlags(app, "recovery build")
+	feeLevel := flags.String("fee", "", "fee level, defaults to normal")
+	feePerKb := flags.Uint64("fee-per-kb", 0, "custom fee rate in satoshis per KB")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("recovery build", args, 3, "[-fee level | -fee-per-kb rate] <address> <height | date> <file>"); err != nil {
+		return err
+	}
+
+	lockTime, err := parseLockTime(args[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	opts := bws.RecoveryOptions{ToAddress: args[0], LockTime: lockTime, FeeLevel: *feeLevel, FeePerKb: *feePerKb}
+	req, err := client.BuildRecoveryProposal(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := writeRecoveryRequest(args[2], req); err != nil {
+		return err
+	}
+
+	return app.Print(req.Proposal, func(w io.Writer) { printTxProposal(w, req.Proposal) })
+}
+
+// runRecoverySign verifies recovery request and asks to confirm its destination before signing
+func runRecoverySign(app *App, args []string) error {
+	flags := newFlags(app, "recovery sign")
+	height := flags.Uint("height", 0, "current block height, required for lock time set as block height")
+	yes := flags.Bool("yes", false, "sign without confirmation")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("recovery sign", args, 1, "[-height n] [-yes] <file>"); err != nil {
+		return err
+	}
+
+	if uint64(*height) > math.MaxInt32-1 {
+		return fmt.Errorf("Invalid block height: %d", *height)
+	}
+
+	req, err := readRecoveryRequest(args[0])
+	if err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.VerifyRecovery(req, int32(*height)); err != nil {
+		return err
+	}
+
+	if !*yes {
+		cfg, err := app.Config()
+		if err != nil {
+			return err
+		}
+
+		txp := req.Proposal
+		fmt.Fprintf(app.Stderr, "Recovery to:\t%s\n", txp.Outputs[0].ToAddress)
+		fmt.Fprintf(app.Stderr, "Amount:\t%s\n", formatAmount(txp.Amount, cfg.Coin))
+		fmt.Fprintf(app.Stderr, "Fee:\t%s\n", formatAmount(txp.Fee, cfg.Coin))
+		fmt.Fprintf(app.Stderr, "Locked until:\t%s\n", formatLockTime(txp.LockTime))
+		if err := app.confirm("Sign recovery transaction? [y/N]: "); err != nil {
+			return err
+		}
+	}
+
+	if err := client.SignRecovery(req, int32(*height)); err != nil {
+		return err
+	}
+
+	if err := writeRecoveryRequest(args[0], req); err != nil {
+		return err
+	}
+
+	return app.Print(req.Proposal, func(w io.Writer) { printTxProposal(w, req.Proposal) })
+}
+
+// runRecoveryStore finalizes recovery proposal from request file and stores it in vault
+func runRecoveryStore(app *App, path string) error {
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	req, err := readRecoveryRequest(path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := client.FinalizeRecovery(req.Proposal)
+	if err != nil {
+		return err
+	}
+
+	vault, err := app.RecoveryVault()
+	if err != nil {
+		return err
+	}
+
+	if err := vault.Add(tx); err != nil {
+		return err
+	}
+
+	return printVault(app, vault)
+}
+
+func printRecovery(app *App) error {
+	vault, err := app.RecoveryVault()
+	if err != nil {
+		return err
+	}
+
+	return printVault(app, vault)
+}
+
+func printVault(app *App, vault *bws.RecoveryVault) error {
+	cfg, err := app.Config()
+	if err != nil {
+		return err
+	}
+
+	txs, err := vault.List()
+	if err != nil {
+		return err
+	}
+
+	return app.Print(txs, func(w io.Writer) {
+		fmt.Fprintln(w, "TXID\tLOCKED UNTIL\tTO\tAMOUNT")
+		for _, tx := range txs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tx.TxID, formatLockTime(tx.LockTime), tx.ToAddress, formatAmount(tx.Amount, cfg.Coin))
+		}
+	})
+}
+
+func readRecoveryRequest(path string) (*bws.RecoveryRequest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &bws.RecoveryRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func writeRecoveryRequest(path string, req *bws.RecoveryRequest) error {
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func runFees(app *App, args []string) error {
+	flags := newFlags(app, "fees")
+	inputs := flags.Int("inputs", 1, "number of inputs")
+	outputs := flags.Int("outputs", 2, "number of outputs, including change")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	quotes, err := client.QuoteFees(*inputs, *outputs)
+	if err != nil {
+		return err
+	}
+
+	return app.Print(quotes, func(w io.Writer) {
+		fmt.Fprintln(w, "LEVEL\tFEE PER KB\tBLOCKS\tFEE")
+		for _, quote := range quotes {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", quote.Level, quote.FeePerKb, quote.NumBlocks, formatAmount(quote.Fee, app.cfg.Coin))
+		}
+	})
+}
+
+func runSend(app *App, args []string) error {
+	flags := newFlags(app, "send")
+	feeLevel := flags.String("fee", "", "fee level, defaults to normal")
+	feePerKb := flags.Uint64("fee-per-kb", 0, "custom fee rate in satoshis per KB")
+	message := flags.String("message", "", "proposal message")
+	rbf := flags.Bool("rbf", false, "signal replace-by-fee")
+	confirmed := flags.Bool("confirmed-only", false, "exclude unconfirmed coins")
+	id := flags.String("id", "", "client-chosen proposal ID")
+	coins := flags.String("coins", "", "comma-separated txid:vout coins to spend")
+	strategy := flags.String("select", "", "select coins locally: auto, bnb, knapsack or largest-first")
+	data := flags.String("data", "", "hex-encoded data of additional OP_RETURN output")
+	lockTime := flags.String("locktime", "", "block height before which transaction cannot be mined")
+	dryRun := flags.Bool("dry-run", false, "only estimate proposal without creating it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("send", args, 2, "[-fee level | -fee-per-kb rate] [-message text] [-rbf] [-confirmed-only] [-id id] [-coins list] [-select strategy] [-data hex] [-locktime height] [-dry-run] <address> <amount>"); err != nil {
+		return err
+	}
+
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return err
+	}
+
+	lock := uint32(0)
+	if *lockTime != "" {
+		if lock, err = parseLockTime(*lockTime); err != nil {
+			return err
+		}
+	}
+
+	opts := &bws.TxProposalOptions{
+		Outputs:                 models.NewTxOutputSingle(amount, args[0]),
+		FeeLevel:                *feeLevel,
+		FeePerKb:                *feePerKb,
+		Replaceable:             *rbf,
+		LockTime:                lock,
+		ExcludeUnconfirmedUtxos: *confirmed,
+		TxProposalID:            *id,
+		DryRun:                  *dryRun,
+	}
+
+	if *message != "" {
+		opts.Message = message
+	}
+
+	if *data != "" {
+		bytes, err := utils.ToBytes(*data)
+		if err != nil {
+			return fmt.Errorf("Invalid data: %v", err)
+		}
+
+		output, err := models.NewDataOutput(bytes)
+		if err != nil {
+			return err
+		}
+
+		opts.Outputs = append(opts.Outputs, output)
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	filter := bws.CoinFilter{ExcludeUnconfirmed: *confirmed}
+	if *coins != "" {
+		filter.Outpoints = strings.Split(*coins, ",")
+	}
+
+	var txp *models.TxProposal
+	switch {
+	case *strategy != "":
+		if *strategy == "auto" {
+			*strategy = string(coinselect.StrategyAuto)
+		}
+
+		selection := bws.SelectionOptions{Strategy: coinselect.Strategy(*strategy), Filter: filter}
+		txp, _, err = client.CreateTxProposalWithSelection(opts, selection)
+	case *coins != "":
+		txp, err = client.CreateTxProposalFromCoins(filter, opts)
+	default:
+		txp, err = client.CreateTxProposalWithOptions(opts)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !*dryRun {
+		if txp, err = client.PublishTxProposal(txp); err != nil {
+			return err
+		}
+	}
+
+	return app.Print(txp, func(w io.Writer) { printTxProposal(w, txp) })
+}
+
+func runPay(app *App, args []string) error {
+	flags := newFlags(app, "pay")
+	feeLevel := flags.String("fee", "", "fee level, defaults to normal")
+	feePerKb := flags.Uint64("fee-per-kb", 0, "custom fee rate in satoshis per KB")
+	dryRun := flags.Bool("dry-run", false, "only estimate proposal without creating it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("pay", args, 1, "[-fee level | -fee-per-kb rate] [-dry-run] <uri>"); err != nil {
+		return err
+	}
+
+	cfg, err := app.Config()
+	if err != nil {
+		return err
+	}
+
+	uri, err := bip21.Parse(args[0], cfg)
+	if err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	opts, err := client.TxProposalOptionsFromURI(uri)
+	if err != nil {
+		return err
+	}
+
+	// Fee rate required by payment request cannot be changed
+	if opts.FeePerKb != 0 && (*feeLevel != "" || *feePerKb != 0) {
+		return errors.New("Payment request sets fee rate")
+	}
+
+	if opts.FeePerKb == 0 {
+		opts.FeeLevel = *feeLevel
+		opts.FeePerKb = *feePerKb
+	}
+
+	opts.DryRun = *dryRun
+	txp, err := client.CreateTxProposalWithOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	if !*dryRun {
+		if txp, err = client.PublishTxProposal(txp); err != nil {
+			return err
+		}
+	}
+
+	return app.Print(txp, func(w io.Writer) { printTxProposal(w, txp) })
+}
+
+func runURI(app *App, args []string) error {
+	flags := newFlags(app, "uri")
+	amount := flags.String("amount", "", "requested amount")
+	label := flags.String("label", "", "recipient label")
+	message := flags.String("message", "", "payment message")
+	request := flags.String("r", "", "payment request URL")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := app.Config()
+	if err != nil {
+		return err
+	}
+
+	uri := &bip21.URI{Label: *label, Message: *message, PaymentRequest: *request}
+	if flags.NArg() != 0 {
+		uri.Address = flags.Arg(0)
+	}
+
+	if *amount != "" {
+		if uri.Amount, err = parseAmount(*amount); err != nil {
+			return err
+		}
+	}
+
+	encoded, err := uri.Encode(cfg)
+	if err != nil {
+		return err
+	}
+
+	return app.Print(map[string]string{"uri": encoded}, func(w io.Writer) {
+		fmt.Fprintln(w, encoded)
+	})
+}
+
+func runSweep(app *App, args []string) error {
+	flags := newFlags(app, "sweep")
+	feeLevel := flags.String("fee", "", "fee level, defaults to normal")
+	feePerKb := flags.Uint64("fee-per-kb", 0, "custom fee rate in satoshis per KB")
+	confirmed := flags.Bool("confirmed-only", false, "exclude unconfirmed coins")
+	dryRun := flags.Bool("dry-run", false, "only estimate proposal without creating it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("sweep", args, 1, "[-fee level | -fee-per-kb rate] [-confirmed-only] [-dry-run] <address>"); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	result, err := client.SendMax(bws.SendMaxOptions{
+		ToAddress:               args[0],
+		FeeLevel:                *feeLevel,
+		FeePerKb:                *feePerKb,
+		ExcludeUnconfirmedUtxos: *confirmed,
+		DryRun:                  *dryRun,
+		Publish:                 true,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return app.Print(result, func(w io.Writer) {
+		printTxProposal(w, result.Proposal)
+		if result.UtxosBelowFee != 0 {
+			fmt.Fprintf(w, "Below fee:\t%d coins, %s\n", result.UtxosBelowFee, formatAmount(result.AmountBelowFee, app.cfg.Coin))
+		}
+
+		if result.UtxosAboveMaxSize != 0 {
+			fmt.Fprintf(w, "Above max size:\t%d coins, %s\n", result.UtxosAboveMaxSize, formatAmount(result.AmountAboveMaxSize, app.cfg.Coin))
+		}
+	})
+}
+
+func runTxProposals(app *App, args []string) error {
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	txps, err := client.GetTxProposals()
+	if err != nil {
+		return err
+	}
+
+	return app.Print(txps, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tSTATUS\tAMOUNT\tFEE\tCREATOR\tACTIONS")
+		for _, txp := range txps {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", txp.ID, txp.Status, formatAmount(txp.Amount, txp.Coin), formatAmount(txp.Fee, txp.Coin), txp.CreatorName, len(txp.Actions))
+		}
+	})
+}
+
+func runSign(app *App, args []string) error {
+	if err := requireArgs("sign", args, 1, "<proposal id>"); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	txp, err := client.GetTx(args[0])
+	if err != nil {
+		return err
+	}
+
+	if txp, err = client.SignTxProposal(txp); err != nil {
+		return err
+	}
+
+	return app.Print(txp, func(w io.Writer) { printTxProposal(w, txp) })
+}
+
+func runBump(app *App, args []string) error {
+	flags := newFlags(app, "bump")
+	feeLevel := flags.String("fee", "", "fee level of replacement, defaults to normal")
+	feePerKb := flags.Uint64("fee-per-kb", 0, "custom fee rate of replacement in satoshis per KB")
+	sign := flags.Bool("sign", false, "sign replacement")
+	broadcast := flags.Bool("broadcast", false, "broadcast replacement once accepted, requires -sign")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("bump", args, 1, "[-fee level | -fee-per-kb rate] [-sign] [-broadcast] <proposal id>"); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	original, err := client.GetTx(args[0])
+	if err != nil {
+		return err
+	}
+
+	txp, err := client.BumpFee(original, bws.BumpFeeOptions{
+		FeeLevel:  *feeLevel,
+		FeePerKb:  *feePerKb,
+		Sign:      *sign,
+		Broadcast: *broadcast,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return app.Print(txp, func(w io.Writer) { printTxProposal(w, txp) })
+}
+
+func runCPFP(app *App, args []string) error {
+	flags := newFlags(app, "cpfp")
+	feeLevel := flags.String("fee", "", "target fee level, defaults to normal")
+	feePerKb := flags.Uint64("fee-per-kb", 0, "custom target fee rate in satoshis per KB")
+	dryRun := flags.Bool("dry-run", false, "only estimate proposal without creating it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("cpfp", args, 1, "[-fee level | -fee-per-kb rate] [-dry-run] <txid>"); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	var parent *models.Transaction
+	history := client.TxHistory(bws.HistoryOptions{})
+	for history.Next() {
+		if history.Tx().TxID == args[0] {
+			parent = history.Tx()
+			break
+		}
+	}
+
+	history.Close()
+
+	if err := history.Err(); err != nil {
+		return err
+	}
+
+	if parent == nil {
+		return fmt.Errorf("Transaction not found in history: %s", args[0])
+	}
+
+	txp, plan, err := client.CPFP(parent, bws.CPFPOptions{
+		FeeLevel: *feeLevel,
+		FeePerKb: *feePerKb,
+		DryRun:   *dryRun,
+		Publish:  true,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{"proposal": txp, "plan": plan}
+	return app.Print(result, func(w io.Writer) {
+		printTxProposal(w, txp)
+		fmt.Fprintf(w, "Package:\t%d + %d vbytes at %d sat/KB\n", plan.ParentSize, plan.ChildSize, plan.FeePerKb)
+	})
+}
+
+func runFinalize(app *App, args []string) error {
+	flags := newFlags(app, "finalize")
+	broadcast := flags.Bool("broadcast", false, "broadcast raw transaction")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("finalize", args, 1, "[-broadcast] <proposal id>"); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	txp, err := client.GetTx(args[0])
+	if err != nil {
+		return err
+	}
+
+	rawTx, err := client.FinalizeTxProposal(txp)
+	if err != nil {
+		return err
+	}
+
+	result := map[string]string{"rawTx": rawTx}
+	if *broadcast {
+		txid, err := client.BroadcastRawTx(rawTx, "")
+		if err != nil {
+			return err
+		}
+
+		result["txid"] = *txid
+	}
+
+	return app.Print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "Raw transaction:\t%s\n", rawTx)
+		if txid, ok := result["txid"]; ok {
+			fmt.Fprintf(w, "Transaction:\t%s\n", txid)
+		}
+	})
+}
+
+func runDecode(app *App, args []string) error {
+	flags := newFlags(app, "decode")
+	proposal := flags.Bool("proposal", false, "decode transaction of proposal with given ID")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("decode", args, 1, "[-proposal] <raw tx | proposal id>"); err != nil {
+		return err
+	}
+
+	cfg, err := app.Config()
+	if err != nil {
+		return err
+	}
+
+	params := decoder.Params{Coin: cfg.Coin, Net: cfg.NetParams()}
+	var tx *decoder.Tx
+	if *proposal {
+		client, err := app.Client()
+		if err != nil {
+			return err
+		}
+
+		txp, err := client.GetTx(args[0])
+		if err != nil {
+			return err
+		}
+
+		tx, err = decoder.DecodeTxProposal(txp, params)
+	} else {
+		tx, err = decoder.Decode(args[0], params)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return app.Print(tx, func(w io.Writer) { printDecodedTx(w, tx, cfg.Coin) })
+}
+
+func runReject(app *App, args []string) error {
+	if err := requireArgs("reject", args, 1, "<proposal id> [reason]"); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	txp, err := client.RejectTxProposal(args[0], strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+
+	return app.Print(txp, func(w io.Writer) { printTxProposal(w, txp) })
+}
+
+func runBroadcast(app *App, args []string) error {
+	flags := newFlags(app, "broadcast")
+	verify := flags.Bool("verify", false, "assemble and verify signed transaction locally before broadcasting")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("broadcast", args, 1, "[-verify] <proposal id>"); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	broadcast := client.BroadcastTxProposal
+	if *verify {
+		broadcast = client.BroadcastTxProposalChecked
+	}
+
+	txp, err := broadcast(args[0])
+	if err != nil {
+		return err
+	}
+
+	return app.Print(txp, func(w io.Writer) { printTxProposal(w, txp) })
+}
+
+func runHistory(app *App, args []string) error {
+	flags := newFlags(app, "history")
+	skip := flags.Uint64("skip", 0, "number of transactions to skip")
+	limit := flags.Uint64("limit", 10, "maximum number of transactions")
+	all := flags.Bool("all", false, "walk the whole history")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	var txs []*models.Transaction
+	if *all {
+		txs, err = client.TxHistory(bws.HistoryOptions{Prefetch: 2}).All()
+	} else {
+		txs, err = client.GetTxHistory(*skip, *limit, false)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	coin := app.cfg.Coin
+	return app.Print(txs, func(w io.Writer) {
+		fmt.Fprintln(w, "TIME\tACTION\tAMOUNT\tCONFIRMATIONS\tTXID")
+		for _, tx := range txs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", formatTime(tx.Time), tx.Action, formatAmount(tx.Amount, coin), tx.Confirmations, tx.TxID)
+		}
+	})
+}
+
+func runNotifications(app *App, args []string) error {
+	flags := newFlags(app, "notifications")
+	last := flags.String("last", "", "last seen notification ID")
+	span := flags.Uint64("span", 0, "time span in seconds")
+	own := flags.Bool("own", false, "include own notifications")
+	follow := flags.Bool("follow", false, "receive notifications until interrupted")
+	interval := flags.Duration("interval", 10*time.Second, "fallback polling interval when following")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	if *follow {
+		return followNotifications(app, client, *last, *own, *interval)
+	}
+
+	notifications, err := client.GetNotifications(*last, *span, *own)
+	if err != nil {
+		return err
+	}
+
+	return app.Print(notifications, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tTIME\tTYPE\tDETAILS")
+		for _, notification := range notifications {
+			printNotification(w, notification, app.cfg.Coin)
+		}
+	})
+}
+
+func followNotifications(app *App, client *bws.Client, last string, own bool, interval time.Duration) error {
+	store := &bws.MemoryCursorStore{}
+	store.Save(last)
+
+	// Socket notifications, falling back to polling if socket is unavailable
+	sub := client.NewRealtime(bws.RealtimeOptions{
+		PollInterval: interval,
+		IncludeOwn:   own,
+		Store:        store,
+		OnError: func(err error) {
+			fmt.Fprintf(app.Stderr, "Error: %s\n", err.Error())
+		},
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result := make(chan error, 1)
+	go func() { result <- sub.Run(ctx) }()
+
+	for notification := range sub.Notifications() {
+		err := app.Print(notification, func(w io.Writer) {
+			printNotification(w, notification, app.cfg.Coin)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := <-result; err != context.Canceled {
+		return err
+	}
+
+	return nil
+}
+
+func runScan(app *App, args []string) error {
+	flags := newFlags(app, "scan")
+	branches := flags.Bool("copayer-branches", false, "include copayer branches")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	scan, err := client.StartScan(*branches)
+	if err != nil {
+		return err
+	}
+
+	return app.Print(scan, func(w io.Writer) {
+		fmt.Fprintf(w, "Scan started:\t%t\n", scan.Started)
+	})
+}
+
+func runPreferences(app *App, args []string) error {
+	flags := newFlags(app, "preferences")
+	flags.String("email", "", "notification email, empty to clear")
+	flags.String("language", "", "notification language, e.g. en")
+	flags.String("unit", "", "amount unit: "+strings.Join(models.PreferenceUnits, ", "))
+	tokens := flags.String("token-addresses", "", "comma-separated token addresses")
+	platform := flags.String("push-platform", "", "push platform: "+strings.Join(models.PushPlatforms, ", "))
+	token := flags.String("push-token", "", "push device token")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// Only explicitly set flags are sent
+	update := &models.PreferencesUpdate{}
+	flags.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		switch f.Name {
+		case "email":
+			update.Email = &value
+		case "language":
+			update.Language = &value
+		case "unit":
+			update.Unit = &value
+		case "token-addresses":
+			update.TokenAddresses = []string{}
+			if *tokens != "" {
+				update.TokenAddresses = strings.Split(*tokens, ",")
+			}
+		}
+	})
+
+	if *platform != "" || *token != "" {
+		update.Push = []*models.PushSubscription{{Platform: *platform, Token: *token}}
+	}
+
+	if err := update.Validate(); err != nil {
+		return err
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	if err := client.UpdatePreferences(update); err != nil {
+		return err
+	}
+
+	prefs, err := client.GetPreferences()
+	if err != nil {
+		return err
+	}
+
+	return app.Print(prefs, func(w io.Writer) {
+		fmt.Fprintf(w, "Email:\t%s\n", prefs.Email)
+		fmt.Fprintf(w, "Language:\t%s\n", prefs.Language)
+		fmt.Fprintf(w, "Unit:\t%s\n", prefs.Unit)
+		if len(prefs.TokenAddresses) != 0 {
+			fmt.Fprintf(w, "Tokens:\t%s\n", strings.Join(prefs.TokenAddresses, ", "))
+		}
+	})
+}
+
+func runFiatRate(app *App, args []string) error {
+	flags := newFlags(app, "fiatrate")
+	provider := flags.String("provider", "", "rate provider")
+	ts := flags.Int64("ts", 0, "UNIX timestamp, defaults to now")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if err := requireArgs("fiatrate", args, 1, "[-provider name] [-ts unix time] <currency code>"); err != nil {
+		return err
+	}
+
+	at := time.Now()
+	if *ts != 0 {
+		at = time.Unix(*ts, 0)
+	}
+
+	client, err := app.Client()
+	if err != nil {
+		return err
+	}
+
+	rate, err := client.GetFiatRate(strings.ToUpper(args[0]), *provider, at)
+	if err != nil {
+		return err
+	}
+
+	return app.Print(rate, func(w io.Writer) {
+		fmt.Fprintf(w, "Rate:\t%v %s\n", rate.Rate, strings.ToUpper(args[0]))
+	})
+}
+
+func printTxProposal(w io.Writer, txp *models.TxProposal) {
+	fmt.Fprintf(w, "Proposal:\t%s\n", txp.ID)
+	fmt.Fprintf(w, "Status:\t%s\n", txp.Status)
+	for _, output := range txp.Outputs {
+		if data, err := output.Data(); err == nil {
+			fmt.Fprintf(w, "Data:\t%s\n", utils.ToHex(data))
+			continue
+		}
+
+		fmt.Fprintf(w, "To:\t%s %s\n", output.ToAddress, formatAmount(output.Amount, txp.Coin))
+	}
+
+	fmt.Fprintf(w, "Fee:\t%s\n", formatAmount(txp.Fee, txp.Coin))
+	if txp.LockTime != 0 {
+		fmt.Fprintf(w, "Locked until:\t%s\n", formatLockTime(txp.LockTime))
+	}
+
+	for _, action := range txp.Actions {
+		fmt.Fprintf(w, "Action:\t%s by %s\n", action.Type, action.CopayerName)
+	}
+
+	if txp.TxID != "" {
+		fmt.Fprintf(w, "Transaction:\t%s\n", txp.TxID)
+	}
+}
+
+func printDecodedTx(w io.Writer, tx *decoder.Tx, coin string) {
+	fmt.Fprintf(w, "Transaction:\t%s\n", tx.TxID)
+	fmt.Fprintf(w, "Version:\t%d\n", tx.Version)
+	fmt.Fprintf(w, "Lock time:\t%d\n", tx.LockTime)
+	fmt.Fprintf(w, "Size:\t%d bytes, %d vbytes\n", tx.Size, tx.VSize)
+	for _, input := range tx.Inputs {
+		amount := ""
+		if input.Amount != nil {
+			amount = formatAmount(*input.Amount, coin)
+		}
+
+		fmt.Fprintf(w, "Input:\t%s %s %s (sequence %d)\n", input.Outpoint, input.Address, amount, input.Sequence)
+	}
+
+	for _, output := range tx.Outputs {
+		if output.Data != "" {
+			fmt.Fprintf(w, "Output:\t%d OP_RETURN %s\n", output.Index, output.Data)
+			continue
+		}
+
+		fmt.Fprintf(w, "Output:\t%d %s %s\n", output.Index, output.Address, formatAmount(output.Amount, coin))
+	}
+
+	if tx.Fee != nil {
+		fmt.Fprintf(w, "Fee:\t%s (%d sat/KB)\n", formatAmount(*tx.Fee, coin), *tx.FeePerKb)
+	}
+}
+
+func printNotification(w io.Writer, notification *models.Notification, coin string) {
+	details := ""
+	payload, err := notification.Payload()
+	switch p := payload.(type) {
+	case *models.NewIncomingTxPayload:
+		details = fmt.Sprintf("%s to %s", formatAmount(p.Amount, coin), p.Address)
+	case *models.NewOutgoingTxPayload:
+		details = fmt.Sprintf("%s in %s", formatAmount(p.Amount, coin), p.TxID)
+	case *models.NewTxProposalPayload:
+		details = fmt.Sprintf("%s in %s", formatAmount(p.Amount, coin), p.TxProposalID)
+	case *models.TxProposalAcceptedByPayload:
+		details = fmt.Sprintf("%s by %s", p.TxProposalID, p.CopayerID)
+	case *models.TxProposalRejectedByPayload:
+		details = fmt.Sprintf("%s by %s", p.TxProposalID, p.CopayerID)
+	case *models.TxProposalFinallyAcceptedPayload:
+		details = p.TxProposalID
+	case *models.TxConfirmationPayload:
+		details = p.TxID
+	case *models.NewCopayerPayload:
+		details = p.CopayerName
+	}
+
+	if err != nil {
+		details = err.Error()
+	}
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", notification.ID, formatTime(uint(notification.CreatedOn)), notification.Type, details)
+}
@@ -0,0 +1,318 @@
+// Package main implements bws, command-line wallet on top of Bitcore Wallet Client
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bws "github.com/pavel-main/bws-go/client"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/credentials"
+)
+
+// Environment variables used by command-line tool
+const (
+	envConfig      = "BWS_CONFIG"
+	envCredentials = "BWS_CREDENTIALS"
+	envPassword    = "BWS_PASSWORD"
+	envFrozen      = "BWS_FROZEN"
+	envRecovery    = "BWS_RECOVERY"
+	envWalletKey   = "BWS_WALLET_KEY"
+)
+
+// Command represents single CLI sub-command
+type Command struct {
+	Name    string
+	Usage   string
+	Summary string
+	Run     func(app *App, args []string) error
+}
+
+// App holds global options and lazily initialized client
+type App struct {
+	ConfigPath      string
+	Profile         string
+	CredentialsPath string
+	FrozenPath      string
+	RecoveryPath    string
+	WalletKey       string
+	JSON            bool
+	Stdin           io.Reader
+	Stdout          io.Writer
+	Stderr          io.Writer
+
+	cfg         *config.Config
+	credentials *credentials.Credentials
+	client      *bws.Client
+}
+
+func main() {
+	app := &App{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+	if err := app.Run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// Run parses global flags and executes sub-command
+func (app *App) Run(args []string) error {
+	home, _ := os.UserHomeDir()
+	defaultConfig := envOrDefault(envConfig, filepath.Join(home, ".bws", "config.yaml"))
+	defaultCredentials := envOrDefault(envCredentials, filepath.Join(home, ".bws", "credentials.enc"))
+	defaultFrozen := envOrDefault(envFrozen, filepath.Join(home, ".bws", "frozen.json"))
+	defaultRecovery := envOrDefault(envRecovery, filepath.Join(home, ".bws", "recovery.enc"))
+
+	flags := flag.NewFlagSet("bws", flag.ContinueOnError)
+	flags.SetOutput(app.Stderr)
+	flags.StringVar(&app.ConfigPath, "config", defaultConfig, "path to YAML profiles file")
+	flags.StringVar(&app.Profile, "profile", "", "configuration profile name")
+	flags.StringVar(&app.CredentialsPath, "credentials", defaultCredentials, "path to encrypted credentials file")
+	flags.StringVar(&app.FrozenPath, "frozen", defaultFrozen, "path to frozen coins list")
+	flags.StringVar(&app.RecoveryPath, "recovery", defaultRecovery, "path to encrypted recovery transactions vault")
+	flags.StringVar(&app.WalletKey, "wallet-key", os.Getenv(envWalletKey), "public key of wallet invitation, required to verify proposals of wallets created by other copayer")
+	flags.BoolVar(&app.JSON, "json", false, "print output as JSON")
+	flags.Usage = func() { app.usage(flags) }
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("Command not specified")
+	}
+
+	name := flags.Arg(0)
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd.Run(app, flags.Args()[1:])
+		}
+	}
+
+	flags.Usage()
+	return fmt.Errorf("Unknown command: %s", name)
+}
+
+func (app *App) usage(flags *flag.FlagSet) {
+	fmt.Fprintf(app.Stderr, "Usage: bws [options] <command> [arguments]\n\nOptions:\n")
+	flags.PrintDefaults()
+	fmt.Fprintf(app.Stderr, "\nCommands:\n")
+
+	sorted := make([]*Command, len(commands))
+	copy(sorted, commands)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, cmd := range sorted {
+		fmt.Fprintf(app.Stderr, "  %-14s %s\n", cmd.Name, cmd.Summary)
+		if cmd.Usage != "" {
+			fmt.Fprintf(app.Stderr, "  %-14s %s\n", "", cmd.Usage)
+		}
+	}
+}
+
+// Config loads configuration profile, or falls back to environment if profiles file is missing
+func (app *App) Config() (*config.Config, error) {
+	if app.cfg != nil {
+		return app.cfg, nil
+	}
+
+	var cfg *config.Config
+	var err error
+	if _, statErr := os.Stat(app.ConfigPath); statErr == nil {
+		cfg, err = config.LoadProfile(app.ConfigPath, app.Profile)
+	} else if app.Profile != "" {
+		return nil, fmt.Errorf("Profiles file not found: %s", app.ConfigPath)
+	} else {
+		cfg, err = config.NewFromEnv()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	app.cfg = cfg
+	return cfg, nil
+}
+
+// Client decrypts credentials and creates BWS client
+func (app *App) Client() (*bws.Client, error) {
+	if app.client != nil {
+		return app.client, nil
+	}
+
+	cfg, err := app.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	if app.credentials == nil {
+		data, err := ioutil.ReadFile(app.CredentialsPath)
+		if err != nil {
+			return nil, err
+		}
+
+		password, err := app.password("Password: ")
+		if err != nil {
+			return nil, err
+		}
+
+		keys, err := credentials.NewFromEncrypted(cfg, data, password)
+		if err != nil {
+			return nil, err
+		}
+
+		app.credentials = keys
+	}
+
+	client, err := bws.New(cfg, app.credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.WalletKey != "" {
+		if err := client.SetWalletPubKey(app.WalletKey); err != nil {
+			return nil, fmt.Errorf("Invalid wallet key: %v", err)
+		}
+	}
+
+	client.SetFreezeList(app.FreezeList())
+	app.client = client
+	return client, nil
+}
+
+// FreezeList opens frozen coins list, coins in it are never spent or signed
+func (app *App) FreezeList() *bws.FreezeList {
+	return bws.NewFreezeList(&bws.FileFreezeStore{Path: app.FrozenPath})
+}
+
+// RecoveryVault opens encrypted vault of pre-signed recovery transactions
+func (app *App) RecoveryVault() (*bws.RecoveryVault, error) {
+	password, err := app.password("Vault password: ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(app.RecoveryPath), 0700); err != nil {
+		return nil, err
+	}
+
+	return bws.NewRecoveryVault(&bws.FileRecoveryStore{Path: app.RecoveryPath, Passphrase: password}), nil
+}
+
+// EncryptCredentials encrypts credentials with new password, refusing to overwrite existing file,
+// so they can be stored with WriteCredentials once wallet is set up on server
+func (app *App) EncryptCredentials(keys *credentials.Credentials) ([]byte, error) {
+	if _, err := os.Stat(app.CredentialsPath); err == nil {
+		return nil, fmt.Errorf("Credentials file already exists: %s", app.CredentialsPath)
+	}
+
+	password, err := app.password("New password: ")
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := keys.Encrypt(password)
+	if err != nil {
+		return nil, err
+	}
+
+	app.credentials = keys
+	return data, nil
+}
+
+// WriteCredentials stores encrypted credentials, refusing to overwrite existing file
+func (app *App) WriteCredentials(data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(app.CredentialsPath), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(app.CredentialsPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return fmt.Errorf("Credentials file already exists: %s", app.CredentialsPath)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// password reads password from environment or prompts for it
+func (app *App) password(prompt string) (string, error) {
+	if password, ok := os.LookupEnv(envPassword); ok {
+		return password, nil
+	}
+
+	password, err := app.prompt(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if password == "" {
+		return "", errors.New("Password must not be empty")
+	}
+
+	return password, nil
+}
+
+// confirm asks user to confirm action, anything but yes cancels it
+func (app *App) confirm(prompt string) error {
+	answer, err := app.prompt(prompt)
+	if err != nil {
+		return err
+	}
+
+	if answer := strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return errors.New("Cancelled")
+	}
+
+	return nil
+}
+
+// prompt reads single line from standard input byte by byte, leaving the rest to following prompts
+func (app *App) prompt(prompt string) (string, error) {
+	fmt.Fprint(app.Stderr, prompt)
+	line := []byte{}
+	char := make([]byte, 1)
+	for {
+		n, err := app.Stdin.Read(char)
+		if n == 1 {
+			if char[0] == '\n' {
+				break
+			}
+
+			line = append(line, char[0])
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
+func envOrDefault(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return fallback
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	bws "github.com/pavel-main/bws-go/client"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/credentials"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var rootKey = "tprv8ZgxMBicQKsPf1Zu9VstrFcmfHVRBibGLcTKn4ZxEYZkxR8fzUQsj1B49LRze1JpL2GAkL5GbqingWSqcW3cNNngt736xpeLJbYE6mHjaRr"
+
+func newTestApp(t *testing.T, expected interface{}) (*App, *bytes.Buffer, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(res).Encode(expected)
+	}))
+
+	dir, err := ioutil.TempDir("", "bws-cli")
+	if err != nil {
+		assert.FailNow(t, "Error creating temporary directory", err)
+	}
+
+	profiles := "profiles:\n  default:\n    baseUrl: " + server.URL + "\n    network: testnet\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte(profiles), 0600); err != nil {
+		assert.FailNow(t, "Error writing profiles", err)
+	}
+
+	keys, _ := credentials.NewFromPrivateKey(config.NewPublicTestnet(), rootKey)
+	data, _ := keys.Encrypt("secret")
+	if err := ioutil.WriteFile(filepath.Join(dir, "credentials.enc"), data, 0600); err != nil {
+		assert.FailNow(t, "Error writing credentials", err)
+	}
+
+	stdout := &bytes.Buffer{}
+	app := &App{
+		ConfigPath:      filepath.Join(dir, "config.yaml"),
+		CredentialsPath: filepath.Join(dir, "credentials.enc"),
+		Stdin:           strings.NewReader("secret\n"),
+		Stdout:          stdout,
+		Stderr:          ioutil.Discard,
+	}
+
+	cleanup := func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+
+	return app, stdout, cleanup
+}
+
+func TestRunBalance(t *testing.T) {
+	balance := &models.Balance{TotalAmount: 150000000, AvailableAmount: 100000000}
+	app, stdout, cleanup := newTestApp(t, balance)
+	defer cleanup()
+
+	err := app.Run([]string{"-config", app.ConfigPath, "-credentials", app.CredentialsPath, "-json", "balance"})
+	assert.NoError(t, err, "should run balance command")
+
+	response := &models.Balance{}
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), response), "should print JSON")
+	assert.Equal(t, balance, response, "balances should match")
+
+	app, stdout, cleanup = newTestApp(t, balance)
+	defer cleanup()
+
+	err = app.Run([]string{"-config", app.ConfigPath, "-credentials", app.CredentialsPath, "balance"})
+	assert.NoError(t, err, "should run balance command")
+	assert.Contains(t, stdout.String(), "1.50000000 BTC", "should print human-readable total")
+}
+
+func TestRunErrors(t *testing.T) {
+	app, _, cleanup := newTestApp(t, nil)
+	defer cleanup()
+
+	assert.Error(t, app.Run([]string{}), "should fail without command")
+	assert.Error(t, app.Run([]string{"unknown"}), "should fail on unknown command")
+	assert.Error(t, app.Run([]string{"-config", app.ConfigPath, "sign"}), "should fail without arguments")
+
+	app.Stdin = strings.NewReader("wrong\n")
+	err := app.Run([]string{"-config", app.ConfigPath, "-credentials", app.CredentialsPath, "balance"})
+	assert.Error(t, err, "should fail on wrong password")
+}
+
+func TestRunCreate(t *testing.T) {
+	app, stdout, cleanup := newTestApp(t, &models.WalletCreate{WalletID: "a0d5a9e1-8b2b-4b57-8a44-6b1c56c8a5b1"})
+	defer cleanup()
+
+	os.Setenv(envPassword, "secret")
+	defer os.Unsetenv(envPassword)
+
+	profiles, _ := ioutil.ReadFile(app.ConfigPath)
+	os.Remove(app.CredentialsPath)
+	ioutil.WriteFile(app.ConfigPath, []byte("profiles:\n  default:\n    baseUrl: http://127.0.0.1:1\n    network: testnet\n"), 0600)
+
+	run := func() error {
+		return (&App{Stdin: app.Stdin, Stdout: stdout, Stderr: app.Stderr}).Run([]string{"-config", app.ConfigPath, "-credentials", app.CredentialsPath, "create", "wallet", "1", "1"})
+	}
+
+	assert.Error(t, run(), "should fail when server is unreachable")
+	_, err := os.Stat(app.CredentialsPath)
+	assert.True(t, os.IsNotExist(err), "should not save credentials of failed attempt")
+
+	ioutil.WriteFile(app.ConfigPath, profiles, 0600)
+	assert.NoError(t, run(), "should retry after failed attempt")
+	assert.Contains(t, stdout.String(), "Mnemonic")
+	_, err = os.Stat(app.CredentialsPath)
+	assert.NoError(t, err, "should save credentials")
+
+	assert.Error(t, run(), "should not overwrite credentials")
+}
+
+func TestParseAmount(t *testing.T) {
+	fixtures := map[string]int64{
+		"1000":       1000,
+		"1000sat":    1000,
+		"0.5btc":     50000000,
+		"1 BCH":      100000000,
+		"12.34bits":  1234,
+		"0.00000001": 0,
+	}
+
+	for input, expected := range fixtures {
+		amount, err := parseAmount(input)
+		if expected == 0 {
+			assert.Error(t, err, "should fail on %s", input)
+			continue
+		}
+
+		assert.NoError(t, err, "should parse %s", input)
+		assert.Equal(t, expected, amount, "amounts should match for %s", input)
+	}
+
+	for _, input := range []string{"", "btc", "-1", "1.2.3", "0.123456789btc", "abc"} {
+		_, err := parseAmount(input)
+		assert.Error(t, err, "should fail on %s", input)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "0.00012345 BTC", formatAmount(12345, "btc"), "should format amount")
+	assert.Equal(t, "-1.00000000 BCH", formatAmount(-100000000, "bch"), "should format negative amount")
+}
+
+func TestRunFreeze(t *testing.T) {
+	app, stdout, cleanup := newTestApp(t, nil)
+	defer cleanup()
+
+	coin := strings.Repeat("aa", 32) + ":0"
+	frozen := filepath.Join(filepath.Dir(app.ConfigPath), "frozen.json")
+	run := func(args ...string) error {
+		stdout.Reset()
+		return app.Run(append([]string{"-frozen", frozen, "freeze"}, args...))
+	}
+
+	assert.NoError(t, run("add", coin, "legal", "hold"), "should freeze coin")
+	assert.NoError(t, run("note", coin, "dispute"), "should annotate coin")
+	assert.Contains(t, stdout.String(), "dispute", "should print note")
+	assert.Error(t, run("note", strings.Repeat("bb", 32)+":0", "unknown"), "should fail on coin that is not frozen")
+	assert.Error(t, run("add"), "should require outpoint")
+	assert.Error(t, run("add", "aa:0"), "should reject invalid outpoint")
+
+	assert.NoError(t, run("remove", coin), "should unfreeze coin")
+	assert.NotContains(t, stdout.String(), coin, "should not list unfrozen coin")
+}
+
+func TestParseLockTime(t *testing.T) {
+	lockTime, err := parseLockTime("650000")
+	assert.NoError(t, err, "should parse block height")
+	assert.Equal(t, uint32(650000), lockTime)
+	assert.Equal(t, "block 650000", formatLockTime(lockTime))
+
+	lockTime, err = parseLockTime("2030-01-01T00:00:00Z")
+	assert.NoError(t, err, "should parse date")
+	assert.Equal(t, uint32(1893456000), lockTime)
+	assert.Equal(t, "2030-01-01T00:00:00Z", formatLockTime(lockTime))
+
+	for _, input := range []string{"", "tomorrow", "500000000", "1970-01-01T00:00:00Z", "2200-01-01T00:00:00Z"} {
+		_, err := parseLockTime(input)
+		assert.Error(t, err, "should fail on %s", input)
+	}
+}
+
+func TestRunSendLockTime(t *testing.T) {
+	app, _, cleanup := newTestApp(t, nil)
+	defer cleanup()
+
+	send := func(lockTime string) error {
+		app.Stdin = strings.NewReader("secret\n")
+		return app.Run([]string{"-config", app.ConfigPath, "-credentials", app.CredentialsPath, "send", "-locktime", lockTime, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", "1000"})
+	}
+
+	err := send("soon")
+	assert.EqualError(t, err, "Invalid lock time: soon")
+
+	err = send("2030-01-01T00:00:00Z")
+	assert.Error(t, err, "should reject lock date of proposal")
+	assert.Contains(t, err.Error(), "only supported by recovery transactions")
+}
+
+func TestRunRecovery(t *testing.T) {
+	app, stdout, cleanup := newTestApp(t, nil)
+	defer cleanup()
+
+	os.Setenv(envPassword, "secret")
+	defer os.Unsetenv(envPassword)
+
+	recovery := filepath.Join(filepath.Dir(app.ConfigPath), "recovery.enc")
+	vault := bws.NewRecoveryVault(&bws.FileRecoveryStore{Path: recovery, Passphrase: "secret"})
+	vault.Add(&bws.RecoveryTx{TxID: "aa", LockTime: 1893456000, ToAddress: "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", Amount: 99000})
+
+	run := func(args ...string) error {
+		stdout.Reset()
+		return app.Run(append([]string{"-config", app.ConfigPath, "-recovery", recovery, "recovery"}, args...))
+	}
+
+	assert.NoError(t, run(), "should list recovery transactions")
+	assert.Contains(t, stdout.String(), "2030-01-01T00:00:00Z", "should print lock date")
+	assert.Contains(t, stdout.String(), "0.00099000 BTC")
+
+	assert.Error(t, run("build", "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", "soon", "request.json"), "should reject invalid lock time")
+	assert.Error(t, run("sign", "missing.json"), "should require request file")
+	assert.Error(t, run("unknown"), "should reject unknown action")
+
+	assert.NoError(t, run("remove", "aa"), "should remove transaction")
+	assert.NotContains(t, stdout.String(), "aa", "should not list removed transaction")
+
+	os.Setenv(envPassword, "wrong")
+	assert.Error(t, run("list"), "should require vault password")
+}
+
+func TestRunRecoverySign(t *testing.T) {
+	app, stdout, cleanup := newTestApp(t, nil)
+	defer cleanup()
+
+	os.Setenv(envPassword, "secret")
+	defer os.Unsetenv(envPassword)
+
+	// Request sweeping P2PKH coin of test credentials
+	keys, _ := credentials.NewFromPrivateKey(config.NewPublicTestnet(), rootKey)
+	_, pubKey, _ := keys.DeriveFromAccount("m/0/0")
+	address, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), config.NewPublicTestnet().NetParams())
+	script, _ := txscript.PayToAddrScript(address)
+	req := &bws.RecoveryRequest{
+		Wallet: &models.Wallet{M: 1, N: 1, AddressType: models.AddressTypeP2PKH, Copayers: []*models.Copayer{{ID: "me", XPubKey: keys.AccExtPubKey.String()}}},
+		Proposal: &models.TxProposal{
+			WalletM:     1,
+			WalletN:     1,
+			AddressType: models.AddressTypeP2PKH,
+			LockTime:    1893456000,
+			Amount:      59000,
+			Fee:         1000,
+			OutputOrder: []int{0},
+			Inputs:      []*models.TxInput{{TxID: "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", Vout: 1, Satoshis: 60000, Path: "m/0/0", ScriptPubKey: hex.EncodeToString(script)}},
+			Outputs:     models.NewTxOutputSingle(59000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		},
+	}
+
+	path := filepath.Join(filepath.Dir(app.ConfigPath), "request.json")
+	assert.NoError(t, writeRecoveryRequest(path, req))
+
+	stderr := &bytes.Buffer{}
+	app.Stderr = stderr
+	run := func(answer string) error {
+		stdout.Reset()
+		app.Stdin = strings.NewReader(answer)
+		return app.Run([]string{"-config", app.ConfigPath, "-credentials", app.CredentialsPath, "recovery", "sign", path})
+	}
+
+	assert.Error(t, run("n\n"), "should cancel signing when not confirmed")
+	assert.Contains(t, stderr.String(), "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", "should show destination before signing")
+	assert.Contains(t, stderr.String(), "0.00059000 BTC", "should show amount before signing")
+	saved, _ := readRecoveryRequest(path)
+	assert.Empty(t, saved.Proposal.Actions, "should not sign cancelled request")
+
+	assert.NoError(t, run("y\n"), "should sign confirmed request")
+	saved, _ = readRecoveryRequest(path)
+	assert.Len(t, saved.Proposal.Actions, 1, "should save signatures")
+}
+
+func TestRunURI(t *testing.T) {
+	app, stdout, cleanup := newTestApp(t, nil)
+	defer cleanup()
+
+	err := app.Run([]string{"-config", app.ConfigPath, "uri", "-amount", "0.5btc", "-label", "Shop & Co", "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"})
+	assert.NoError(t, err, "should build URI")
+	assert.Equal(t, "bitcoin:mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY?amount=0.5&label=Shop%20%26%20Co\n", stdout.String())
+
+	err = app.Run([]string{"-config", app.ConfigPath, "uri", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"})
+	assert.Error(t, err, "should reject address of other network")
+
+	err = app.Run([]string{"-config", app.ConfigPath, "pay", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"})
+	assert.Error(t, err, "should reject URI of other coin")
+}
+
+func TestRunDecode(t *testing.T) {
+	app, stdout, cleanup := newTestApp(t, nil)
+	defer cleanup()
+
+	rawTx := "0100000001b47949a5bd338e1ec86a51b4668714d71997cd5df298275324dcf3d887165e0d0100000000ffffffff02326ab900000000001976a91426e7365e8b0a0bae05e7cfc320f8dc338dfdfa6b88aca4e31d00000000001976a914512c17fd86d596deb44c230ae4a98efae01f013c88ac00000000"
+	err := app.Run([]string{"-config", app.ConfigPath, "decode", rawTx})
+	assert.NoError(t, err, "should decode raw transaction")
+	assert.Contains(t, stdout.String(), "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY 0.01958820 BTC", "should print outputs")
+
+	assert.Error(t, app.Run([]string{"-config", app.ConfigPath, "decode", "00"}), "should fail on invalid transaction")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pavel-main/bws-go/models"
+)
+
+// Amount units and their decimal places
+var units = map[string]int{
+	"sat":  0,
+	"bits": 2,
+	"btc":  8,
+	"bch":  8,
+}
+
+// Print writes value as JSON, or calls human-readable formatter
+func (app *App) Print(value interface{}, human func(w io.Writer)) error {
+	if app.JSON {
+		encoder := json.NewEncoder(app.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	writer := tabwriter.NewWriter(app.Stdout, 0, 4, 2, ' ', 0)
+	human(writer)
+	return writer.Flush()
+}
+
+// parseAmount converts amount with optional unit suffix (sat, bits, btc, bch) to satoshis
+func parseAmount(input string) (int64, error) {
+	value := strings.ToLower(strings.TrimSpace(input))
+	decimals := 0
+	for unit, places := range units {
+		if strings.HasSuffix(value, unit) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit))
+			decimals = places
+			break
+		}
+	}
+
+	parts := strings.Split(value, ".")
+	if len(parts) > 2 || parts[0] == "" && (len(parts) == 1 || parts[1] == "") {
+		return 0, fmt.Errorf("Invalid amount: %s", input)
+	}
+
+	fraction := ""
+	if len(parts) == 2 {
+		fraction = parts[1]
+	}
+
+	if len(fraction) > decimals {
+		return 0, fmt.Errorf("Too many decimal places in amount: %s", input)
+	}
+
+	digits := parts[0] + fraction + strings.Repeat("0", decimals-len(fraction))
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("Invalid amount: %s", input)
+	}
+
+	return amount, nil
+}
+
+// formatAmount converts satoshis to coin units
+func formatAmount(amount int64, coin string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%08d %s", sign, amount/1e8, amount%1e8, strings.ToUpper(coin))
+}
+
+// formatTime converts UNIX timestamp to human-readable time
+func formatTime(ts uint) string {
+	if ts == 0 {
+		return "-"
+	}
+
+	return time.Unix(int64(ts), 0).UTC().Format("2006-01-02 15:04:05")
+}
+
+// requireArgs validates number of positional arguments
+func requireArgs(cmd string, args []string, count int, usage string) error {
+	if len(args) < count {
+		return errors.New("Usage: bws " + cmd + " " + usage)
+	}
+
+	return nil
+}
+
+// parseLockTime converts block height or RFC 3339 date to transaction lock time
+func parseLockTime(input string) (uint32, error) {
+	if height, err := strconv.ParseUint(input, 10, 32); err == nil {
+		if uint32(height) >= models.LockTimeThreshold {
+			return 0, fmt.Errorf("Block height too large: %s", input)
+		}
+
+		return uint32(height), nil
+	}
+
+	date, err := time.Parse(time.RFC3339, input)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid lock time: %s", input)
+	}
+
+	if date.Unix() < int64(models.LockTimeThreshold) || date.Unix() > math.MaxUint32 {
+		return 0, fmt.Errorf("Lock date out of range: %s", input)
+	}
+
+	return uint32(date.Unix()), nil
+}
+
+// formatLockTime returns lock time as block height or date
+func formatLockTime(lockTime uint32) string {
+	if lockTime >= models.LockTimeThreshold {
+		return time.Unix(int64(lockTime), 0).UTC().Format(time.RFC3339)
+	}
+
+	return fmt.Sprintf("block %d", lockTime)
+}
@@ -0,0 +1,336 @@
+// Package coinselect implements local coin selection algorithms over wallet UTXOs
+package coinselect
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/pavel-main/bws-go/models"
+)
+
+// ErrInsufficientFunds is returned when coins do not cover target and fees
+var ErrInsufficientFunds = errors.New("Insufficient funds")
+
+// Strategy names coin selection algorithm
+type Strategy string
+
+// List of supported strategies
+const (
+	StrategyAuto           Strategy = ""              // Branch and bound, falling back to knapsack
+	StrategyBranchAndBound Strategy = "bnb"           // Changeless spends only
+	StrategyKnapsack       Strategy = "knapsack"      // Randomized subset closest to target
+	StrategyLargestFirst   Strategy = "largest-first" // Largest coins first
+)
+
+const (
+	defaultDustLimit = 546
+	maxBnBTries      = 100000
+	knapsackRounds   = 1000
+)
+
+// Default sizes of P2PKH transaction parts, in bytes
+const (
+	P2PKHBaseSize   = 10
+	P2PKHInputSize  = 148
+	P2PKHOutputSize = 34
+)
+
+// Params describes payment and transaction size model
+type Params struct {
+	Target     int64  // Amount sent to recipients, satoshis
+	FeePerKb   uint64 // Fee rate, satoshis per kilobyte
+	BaseSize   int    // Size of transaction without inputs and change, including recipient outputs
+	InputSize  int    // Size of every input
+	ChangeSize int    // Size of change output
+	DustLimit  int64  // Change below this amount is added to fee, defaults to 546
+}
+
+// DefaultParams returns params of P2PKH transaction with given number of recipients
+func DefaultParams(target int64, feePerKb uint64, outputs int) Params {
+	return Params{
+		Target:     target,
+		FeePerKb:   feePerKb,
+		BaseSize:   P2PKHBaseSize + outputs*P2PKHOutputSize,
+		InputSize:  P2PKHInputSize,
+		ChangeSize: P2PKHOutputSize,
+		DustLimit:  defaultDustLimit,
+	}
+}
+
+// Result represents selected coins
+type Result struct {
+	Inputs []*models.TxInput `json:"inputs"`
+	Fee    int64             `json:"fee"`
+	Change int64             `json:"change"` // Zero for changeless transactions
+	Size   int               `json:"size"`   // Estimated transaction size
+}
+
+// Fee returns fee for transaction of given size, rounded up
+func (p Params) Fee(size int) int64 {
+	return int64((uint64(size)*p.FeePerKb + 999) / 1000)
+}
+
+// effective returns coin value after paying for its input
+func (p Params) effective(input *models.TxInput) int64 {
+	return input.Satoshis - p.Fee(p.InputSize)
+}
+
+func (p Params) dustLimit() int64 {
+	if p.DustLimit <= 0 {
+		return defaultDustLimit
+	}
+
+	return p.DustLimit
+}
+
+// Select runs strategy over coins
+func Select(strategy Strategy, coins []*models.TxInput, params Params) (*Result, error) {
+	switch strategy {
+	case StrategyAuto:
+		if result, err := BranchAndBound(coins, params); err == nil {
+			return result, nil
+		}
+
+		return Knapsack(coins, params)
+	case StrategyBranchAndBound:
+		return BranchAndBound(coins, params)
+	case StrategyKnapsack:
+		return Knapsack(coins, params)
+	case StrategyLargestFirst:
+		return LargestFirst(coins, params)
+	}
+
+	return nil, errors.New("Unknown coin selection strategy: " + string(strategy))
+}
+
+// BranchAndBound searches for coins covering target and fees without change output,
+// excess below cost of change is added to fee
+func BranchAndBound(coins []*models.TxInput, params Params) (*Result, error) {
+	candidates := positive(coins, params)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return params.effective(candidates[i]) > params.effective(candidates[j])
+	})
+
+	target := params.Target + params.Fee(params.BaseSize)
+	costOfChange := params.Fee(params.ChangeSize) + params.Fee(params.InputSize)
+
+	// Remaining value after each position, used to prune branches
+	remaining := make([]int64, len(candidates)+1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + params.effective(candidates[i])
+	}
+
+	if remaining[0] < target {
+		return nil, ErrInsufficientFunds
+	}
+
+	var best []int
+	var bestWaste int64 = -1
+	selected := []int{}
+	tries := 0
+
+	var search func(idx int, value int64)
+	search = func(idx int, value int64) {
+		tries++
+		if tries > maxBnBTries || value > target+costOfChange || value+remaining[idx] < target {
+			return
+		}
+
+		if value >= target {
+			if waste := value - target; bestWaste < 0 || waste < bestWaste {
+				bestWaste = waste
+				best = append([]int{}, selected...)
+			}
+
+			return
+		}
+
+		if idx == len(candidates) {
+			return
+		}
+
+		// Include coin, then explore omitting it
+		selected = append(selected, idx)
+		search(idx+1, value+params.effective(candidates[idx]))
+		selected = selected[:len(selected)-1]
+		search(idx+1, value)
+	}
+
+	search(0, 0)
+	if best == nil {
+		return nil, errors.New("No changeless solution found")
+	}
+
+	inputs := []*models.TxInput{}
+	for _, idx := range best {
+		inputs = append(inputs, candidates[idx])
+	}
+
+	return changeless(inputs, params)
+}
+
+// Knapsack picks exact match, smallest sufficient coin, or randomized subset of smaller coins,
+// whichever leaves the least excess
+func Knapsack(coins []*models.TxInput, params Params) (*Result, error) {
+	candidates := positive(coins, params)
+	target := params.Target + params.Fee(params.BaseSize+params.ChangeSize)
+	minChange := params.dustLimit()
+
+	var lowestLarger *models.TxInput
+	smaller := []*models.TxInput{}
+	var smallerTotal int64
+	for _, coin := range candidates {
+		value := params.effective(coin)
+		switch {
+		case value == target:
+			return finalize([]*models.TxInput{coin}, params)
+		case value < target+minChange:
+			smaller = append(smaller, coin)
+			smallerTotal += value
+		case lowestLarger == nil || value < params.effective(lowestLarger):
+			lowestLarger = coin
+		}
+	}
+
+	if smallerTotal == target {
+		return finalize(smaller, params)
+	}
+
+	if smallerTotal < target {
+		if lowestLarger == nil {
+			return nil, ErrInsufficientFunds
+		}
+
+		return finalize([]*models.TxInput{lowestLarger}, params)
+	}
+
+	sort.SliceStable(smaller, func(i, j int) bool {
+		return params.effective(smaller[i]) > params.effective(smaller[j])
+	})
+
+	subset, value := approximateBestSubset(smaller, params, target)
+	if value != target && value < target+minChange {
+		subset, value = approximateBestSubset(smaller, params, target+minChange)
+	}
+
+	if lowestLarger != nil && (value < target || params.effective(lowestLarger) <= value) {
+		return finalize([]*models.TxInput{lowestLarger}, params)
+	}
+
+	return finalize(subset, params)
+}
+
+// approximateBestSubset randomly includes coins to find subset closest to target from above
+func approximateBestSubset(coins []*models.TxInput, params Params, target int64) ([]*models.TxInput, int64) {
+	random := rand.New(rand.NewSource(int64(len(coins)) + target))
+	best := make([]bool, len(coins))
+	var bestValue int64
+	for i, coin := range coins {
+		best[i] = true
+		bestValue += params.effective(coin)
+	}
+
+	included := make([]bool, len(coins))
+	for round := 0; round < knapsackRounds && bestValue != target; round++ {
+		for i := range included {
+			included[i] = false
+		}
+
+		var value int64
+		reached := false
+		for pass := 0; pass < 2 && !reached; pass++ {
+			for i, coin := range coins {
+				// First pass picks coins randomly, second adds the rest
+				if included[i] || (pass == 0 && random.Intn(2) == 0) {
+					continue
+				}
+
+				included[i] = true
+				value += params.effective(coin)
+				if value >= target {
+					reached = true
+					if value < bestValue {
+						bestValue = value
+						copy(best, included)
+					}
+
+					included[i] = false
+					value -= params.effective(coin)
+				}
+			}
+		}
+	}
+
+	subset := []*models.TxInput{}
+	for i, coin := range coins {
+		if best[i] {
+			subset = append(subset, coin)
+		}
+	}
+
+	return subset, bestValue
+}
+
+// LargestFirst spends largest coins until target and fees are covered
+func LargestFirst(coins []*models.TxInput, params Params) (*Result, error) {
+	candidates := positive(coins, params)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Satoshis > candidates[j].Satoshis
+	})
+
+	inputs := []*models.TxInput{}
+	for _, coin := range candidates {
+		inputs = append(inputs, coin)
+		if result, err := finalize(inputs, params); err == nil {
+			return result, nil
+		}
+	}
+
+	return nil, ErrInsufficientFunds
+}
+
+// finalize computes fee and change of selected coins, dropping dust change
+func finalize(inputs []*models.TxInput, params Params) (*Result, error) {
+	var total int64
+	for _, input := range inputs {
+		total += input.Satoshis
+	}
+
+	size := params.BaseSize + len(inputs)*params.InputSize + params.ChangeSize
+	fee := params.Fee(size)
+	change := total - params.Target - fee
+	if change >= params.dustLimit() {
+		return &Result{Inputs: inputs, Fee: fee, Change: change, Size: size}, nil
+	}
+
+	return changeless(inputs, params)
+}
+
+// changeless adds all excess to fee
+func changeless(inputs []*models.TxInput, params Params) (*Result, error) {
+	var total int64
+	for _, input := range inputs {
+		total += input.Satoshis
+	}
+
+	size := params.BaseSize + len(inputs)*params.InputSize
+	fee := total - params.Target
+	if fee < params.Fee(size) {
+		return nil, ErrInsufficientFunds
+	}
+
+	return &Result{Inputs: inputs, Fee: fee, Size: size}, nil
+}
+
+// positive returns coins worth more than fee to spend them
+func positive(coins []*models.TxInput, params Params) []*models.TxInput {
+	result := []*models.TxInput{}
+	for _, coin := range coins {
+		if params.effective(coin) > 0 {
+			result = append(result, coin)
+		}
+	}
+
+	return result
+}
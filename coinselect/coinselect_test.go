@@ -0,0 +1,95 @@
+package coinselect
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func coins(amounts ...int64) []*models.TxInput {
+	result := []*models.TxInput{}
+	for i, amount := range amounts {
+		result = append(result, &models.TxInput{TxID: fmt.Sprintf("%064x", i), Satoshis: amount})
+	}
+
+	return result
+}
+
+func total(inputs []*models.TxInput) int64 {
+	var sum int64
+	for _, input := range inputs {
+		sum += input.Satoshis
+	}
+
+	return sum
+}
+
+// checkResult verifies that selection pays target, fee and change exactly
+func checkResult(t *testing.T, result *Result, params Params) {
+	assert.Equal(t, total(result.Inputs), params.Target+result.Fee+result.Change, "inputs should cover target, fee and change")
+	assert.True(t, result.Fee >= params.Fee(result.Size), "fee should match estimated size")
+	assert.True(t, result.Change == 0 || result.Change >= params.DustLimit, "should not create dust change")
+}
+
+func TestBranchAndBound(t *testing.T) {
+	params := DefaultParams(100000, 1000, 1)
+	// 44 bytes base and 148 per input at 1 sat/byte
+	utxos := coins(50000, 40148, 60192, 148, 70000, 30148)
+
+	result, err := BranchAndBound(utxos, params)
+	assert.NoError(t, err, "should find changeless solution")
+	assert.Equal(t, int64(0), result.Change, "should not create change")
+	assert.Equal(t, int64(100000), total(result.Inputs)-result.Fee, "should spend exactly target")
+	checkResult(t, result, params)
+
+	_, err = BranchAndBound(coins(1000000), params)
+	assert.Error(t, err, "should fail when only change solutions exist")
+
+	_, err = BranchAndBound(coins(1000, 2000), params)
+	assert.Equal(t, ErrInsufficientFunds, err)
+}
+
+func TestKnapsack(t *testing.T) {
+	params := DefaultParams(100000, 1000, 1)
+
+	result, err := Knapsack(coins(1000000, 200000, 150000, 20000), params)
+	assert.NoError(t, err, "should select coins")
+	assert.Equal(t, int64(150000), result.Inputs[0].Satoshis, "should prefer smallest sufficient coin")
+	checkResult(t, result, params)
+
+	result, err = Knapsack(coins(30000, 40000, 50000, 60000), params)
+	assert.NoError(t, err, "should combine smaller coins")
+	assert.True(t, len(result.Inputs) > 1)
+	checkResult(t, result, params)
+
+	_, err = Knapsack(coins(30000, 40000), params)
+	assert.Equal(t, ErrInsufficientFunds, err)
+}
+
+func TestLargestFirst(t *testing.T) {
+	params := DefaultParams(100000, 1000, 1)
+
+	result, err := LargestFirst(coins(20000, 90000, 50000, 10000), params)
+	assert.NoError(t, err, "should select coins")
+	assert.Equal(t, []int64{90000, 50000}, []int64{result.Inputs[0].Satoshis, result.Inputs[1].Satoshis}, "should spend largest coins first")
+	assert.Equal(t, int64(140000-100000-params.Fee(44+2*148+34)), result.Change, "should return change")
+	checkResult(t, result, params)
+
+	// Coins worth less than fee to spend them are ignored
+	_, err = LargestFirst(coins(100, 100, 100), DefaultParams(50, 1000, 1))
+	assert.Equal(t, ErrInsufficientFunds, err)
+}
+
+func TestSelect(t *testing.T) {
+	params := DefaultParams(100000, 1000, 1)
+	utxos := coins(1000000, 300000)
+
+	result, err := Select(StrategyAuto, utxos, params)
+	assert.NoError(t, err, "should fall back to knapsack")
+	checkResult(t, result, params)
+
+	_, err = Select(Strategy("random"), utxos, params)
+	assert.Error(t, err, "should reject unknown strategy")
+}
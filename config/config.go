@@ -4,6 +4,7 @@ package config
 import (
 	"errors"
 	"net/url"
+	"strings"
 
 	"github.com/btcsuite/btcd/chaincfg"
 )
@@ -34,12 +35,16 @@ const (
 
 // Config contains Client configuration
 type Config struct {
-	Debug    bool
-	BaseURL  string
-	Coin     string
-	Network  string
-	Timeout  int
-	Deadline int
+	Debug       bool
+	BaseURL     string
+	Coin        string
+	Network     string
+	Timeout     int
+	Deadline    int
+	Retries     int
+	RetryDelay  int
+	Proxy       string
+	InsecureTLS bool
 }
 
 // NewPublic creates new instance of Config for public API, BTC and livenet
@@ -79,20 +84,12 @@ func NewCashPublicTestnet() *Config {
 
 // NewCustom creates new instance of Config with custom parameters
 func NewCustom(baseURL, coin, network string) (*Config, error) {
-	// Validate input
-	if _, err := url.ParseRequestURI(baseURL); err != nil {
+	cfg := newConfig(baseURL, coin, network)
+	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 
-	if coin != CoinBTC && coin != CoinBCH {
-		return nil, errors.New("Invalid coin name")
-	}
-
-	if network != NetworkLive && network != NetworkTest {
-		return nil, errors.New("Invalid network name")
-	}
-
-	return newConfig(baseURL, coin, network), nil
+	return cfg, nil
 }
 
 func newConfig(baseURL, coin, network string) *Config {
@@ -104,9 +101,56 @@ func newConfig(baseURL, coin, network string) *Config {
 	c.Network = network
 	c.Timeout = 10000
 	c.Deadline = 10000
+	c.Retries = 0
+	c.RetryDelay = 1000
 	return c
 }
 
+// Validate checks that configuration is usable by Client
+func (cfg *Config) Validate() error {
+	if _, err := url.ParseRequestURI(cfg.BaseURL); err != nil {
+		return err
+	}
+
+	if cfg.Coin != CoinBTC && cfg.Coin != CoinBCH {
+		return errors.New("Invalid coin name")
+	}
+
+	if cfg.Network != NetworkLive && cfg.Network != NetworkTest {
+		return errors.New("Invalid network name")
+	}
+
+	if cfg.Timeout < 0 || cfg.Deadline < 0 {
+		return errors.New("Timeouts must not be negative")
+	}
+
+	if cfg.Retries < 0 || cfg.RetryDelay < 0 {
+		return errors.New("Retry settings must not be negative")
+	}
+
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.ProxyURL())
+		if err != nil {
+			return err
+		}
+
+		if proxy.Scheme != "http" || proxy.Host == "" {
+			return errors.New("Only HTTP proxies are supported")
+		}
+	}
+
+	return nil
+}
+
+// ProxyURL returns proxy address with explicit scheme
+func (cfg *Config) ProxyURL() string {
+	if cfg.Proxy == "" || strings.Contains(cfg.Proxy, "://") {
+		return cfg.Proxy
+	}
+
+	return "http://" + cfg.Proxy
+}
+
 // CoinType returns BIP-44 coin type
 func (cfg *Config) CoinType() uint32 {
 	if cfg.Network == NetworkTest {
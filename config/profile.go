@@ -0,0 +1,206 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables overriding profile values
+const (
+	EnvProfile     = "BWS_PROFILE"
+	EnvBaseURL     = "BWS_BASE_URL"
+	EnvCoin        = "BWS_COIN"
+	EnvNetwork     = "BWS_NETWORK"
+	EnvDebug       = "BWS_DEBUG"
+	EnvTimeout     = "BWS_TIMEOUT"
+	EnvDeadline    = "BWS_DEADLINE"
+	EnvRetries     = "BWS_RETRIES"
+	EnvRetryDelay  = "BWS_RETRY_DELAY"
+	EnvProxy       = "BWS_PROXY"
+	EnvInsecureTLS = "BWS_INSECURE_TLS"
+)
+
+const defaultProfile = "default"
+
+// Profile represents named configuration profile, unset values fall back to defaults
+type Profile struct {
+	BaseURL     string `yaml:"baseUrl"`
+	Coin        string `yaml:"coin"`
+	Network     string `yaml:"network"`
+	Debug       *bool  `yaml:"debug"`
+	Timeout     *int   `yaml:"timeout"`
+	Deadline    *int   `yaml:"deadline"`
+	Retries     *int   `yaml:"retries"`
+	RetryDelay  *int   `yaml:"retryDelay"`
+	Proxy       string `yaml:"proxy"`
+	InsecureTLS *bool  `yaml:"insecureTls"`
+}
+
+// Profiles represents configuration file with a set of named profiles
+type Profiles struct {
+	Default  string              `yaml:"default"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// LoadProfile reads profiles file and returns validated Config for the named profile
+func LoadProfile(path, name string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseProfile(data, name)
+}
+
+// ParseProfile parses YAML profiles and returns validated Config for the named profile
+//
+// Profile name is resolved in the following order: name argument, BWS_PROFILE
+// environment variable, "default" key of the file, and finally "default".
+// Environment variables take precedence over values from the file.
+func ParseProfile(data []byte, name string) (*Config, error) {
+	profiles := &Profiles{}
+	if err := yaml.Unmarshal(data, profiles); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = os.Getenv(EnvProfile)
+	}
+
+	if name == "" {
+		name = profiles.Default
+	}
+
+	if name == "" {
+		name = defaultProfile
+	}
+
+	profile, ok := profiles.Profiles[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("Profile not found: %s", name)
+	}
+
+	return profile.Config()
+}
+
+// NewFromEnv creates Config for public API, BTC and livenet with environment overrides applied
+func NewFromEnv() (*Config, error) {
+	return (&Profile{}).Config()
+}
+
+// Config converts profile into validated Config with environment overrides applied
+func (p *Profile) Config() (*Config, error) {
+	cfg := newConfig(publicAPI, CoinBTC, NetworkLive)
+	p.apply(cfg)
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (p *Profile) apply(cfg *Config) {
+	if p.BaseURL != "" {
+		cfg.BaseURL = p.BaseURL
+	}
+
+	if p.Coin != "" {
+		cfg.Coin = p.Coin
+	}
+
+	if p.Network != "" {
+		cfg.Network = p.Network
+	}
+
+	if p.Debug != nil {
+		cfg.Debug = *p.Debug
+	}
+
+	if p.Timeout != nil {
+		cfg.Timeout = *p.Timeout
+	}
+
+	if p.Deadline != nil {
+		cfg.Deadline = *p.Deadline
+	}
+
+	if p.Retries != nil {
+		cfg.Retries = *p.Retries
+	}
+
+	if p.RetryDelay != nil {
+		cfg.RetryDelay = *p.RetryDelay
+	}
+
+	if p.Proxy != "" {
+		cfg.Proxy = p.Proxy
+	}
+
+	if p.InsecureTLS != nil {
+		cfg.InsecureTLS = *p.InsecureTLS
+	}
+}
+
+func applyEnv(cfg *Config) error {
+	if value, ok := os.LookupEnv(EnvBaseURL); ok {
+		cfg.BaseURL = value
+	}
+
+	if value, ok := os.LookupEnv(EnvCoin); ok {
+		cfg.Coin = value
+	}
+
+	if value, ok := os.LookupEnv(EnvNetwork); ok {
+		cfg.Network = value
+	}
+
+	if value, ok := os.LookupEnv(EnvProxy); ok {
+		cfg.Proxy = value
+	}
+
+	bools := map[string]*bool{
+		EnvDebug:       &cfg.Debug,
+		EnvInsecureTLS: &cfg.InsecureTLS,
+	}
+
+	for key, target := range bools {
+		if value, ok := os.LookupEnv(key); ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.New("Invalid boolean value in " + key)
+			}
+
+			*target = parsed
+		}
+	}
+
+	ints := map[string]*int{
+		EnvTimeout:    &cfg.Timeout,
+		EnvDeadline:   &cfg.Deadline,
+		EnvRetries:    &cfg.Retries,
+		EnvRetryDelay: &cfg.RetryDelay,
+	}
+
+	for key, target := range ints {
+		if value, ok := os.LookupEnv(key); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.New("Invalid integer value in " + key)
+			}
+
+			*target = parsed
+		}
+	}
+
+	return nil
+}
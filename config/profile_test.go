@@ -0,0 +1,122 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var mockProfiles = []byte(`
+default: staging
+profiles:
+  production:
+    baseUrl: https://bws.bitpay.com/bws/api
+    coin: bch
+    network: livenet
+    timeout: 5000
+    deadline: 20000
+    retries: 3
+  staging:
+    baseUrl: http://localhost:3232/bws/api
+    network: testnet
+    debug: true
+    proxy: 127.0.0.1:8080
+    insecureTls: true
+  broken:
+    coin: eth
+`)
+
+func TestParseProfile(t *testing.T) {
+	cfg, err := ParseProfile(mockProfiles, "production")
+	assert.NoError(t, err, "should load named profile")
+	assert.Equal(t, publicAPI, cfg.BaseURL, "should use profile base URL")
+	assert.Equal(t, CoinBCH, cfg.Coin, "should use profile coin")
+	assert.Equal(t, NetworkLive, cfg.Network, "should use profile network")
+	assert.Equal(t, 5000, cfg.Timeout, "should use profile timeout")
+	assert.Equal(t, 20000, cfg.Deadline, "should use profile deadline")
+	assert.Equal(t, 3, cfg.Retries, "should use profile retries")
+	assert.Equal(t, 1000, cfg.RetryDelay, "should keep default retry delay")
+	assert.False(t, cfg.Debug, "should keep debug disabled")
+}
+
+func TestParseProfileDefault(t *testing.T) {
+	cfg, err := ParseProfile(mockProfiles, "")
+	assert.NoError(t, err, "should load default profile")
+	assert.Equal(t, localAPI, cfg.BaseURL, "should use profile base URL")
+	assert.Equal(t, CoinBTC, cfg.Coin, "should fall back to default coin")
+	assert.Equal(t, NetworkTest, cfg.Network, "should use profile network")
+	assert.Equal(t, "http://127.0.0.1:8080", cfg.ProxyURL(), "should return proxy with scheme")
+	assert.True(t, cfg.Debug, "should enable debug")
+	assert.True(t, cfg.InsecureTLS, "should allow insecure TLS")
+}
+
+func TestParseProfileEnv(t *testing.T) {
+	os.Setenv(EnvProfile, "production")
+	os.Setenv(EnvNetwork, NetworkTest)
+	os.Setenv(EnvRetries, "5")
+	os.Setenv(EnvDebug, "true")
+	defer os.Unsetenv(EnvProfile)
+	defer os.Unsetenv(EnvNetwork)
+	defer os.Unsetenv(EnvRetries)
+	defer os.Unsetenv(EnvDebug)
+
+	cfg, err := ParseProfile(mockProfiles, "")
+	assert.NoError(t, err, "should load profile selected by environment")
+	assert.Equal(t, CoinBCH, cfg.Coin, "should use profile coin")
+	assert.Equal(t, NetworkTest, cfg.Network, "should override network from environment")
+	assert.Equal(t, 5, cfg.Retries, "should override retries from environment")
+	assert.True(t, cfg.Debug, "should override debug from environment")
+
+	os.Setenv(EnvRetries, "many")
+	_, err = ParseProfile(mockProfiles, "")
+	assert.Error(t, err, "should fail on invalid integer")
+}
+
+func TestParseProfileErrors(t *testing.T) {
+	_, err := ParseProfile([]byte("profiles: ["), "")
+	assert.Error(t, err, "should fail on invalid YAML")
+
+	_, err = ParseProfile(mockProfiles, "unknown")
+	assert.Error(t, err, "should fail on missing profile")
+
+	_, err = ParseProfile(mockProfiles, "broken")
+	assert.Error(t, err, "should fail on profile validation")
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bws-config")
+	assert.NoError(t, err, "should create temporary directory")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bws.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, mockProfiles, 0600), "should write profiles file")
+
+	cfg, err := LoadProfile(path, "production")
+	assert.NoError(t, err, "should load profile from file")
+	assert.Equal(t, CoinBCH, cfg.Coin, "should use profile coin")
+
+	_, err = LoadProfile(filepath.Join(dir, "missing.yaml"), "production")
+	assert.Error(t, err, "should fail on missing file")
+}
+
+func TestValidate(t *testing.T) {
+	cfg := NewPublic()
+	assert.NoError(t, cfg.Validate(), "should pass validation")
+
+	cfg.Timeout = -1
+	assert.Error(t, cfg.Validate(), "should fail on negative timeout")
+
+	cfg = NewPublic()
+	cfg.Retries = -1
+	assert.Error(t, cfg.Validate(), "should fail on negative retries")
+
+	cfg = NewPublic()
+	cfg.Proxy = "socks5://127.0.0.1:1080"
+	assert.Error(t, cfg.Validate(), "should fail on non-HTTP proxy")
+
+	cfg.Proxy = "http://127.0.0.1:3128"
+	assert.NoError(t, cfg.Validate(), "should accept HTTP proxy")
+}
@@ -2,18 +2,14 @@
 package credentials
 
 import (
-	"strconv"
-	"strings"
-
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/utils"
 	bip39 "github.com/tyler-smith/go-bip39"
 )
 
-const hardening = "'"
-
 // Credentials contains is BIP-39 Root Key and derivatives
 type Credentials struct {
 	RootKey      *hdkeychain.ExtendedKey
@@ -85,40 +81,12 @@ func newFromMnemonic(mnemonic, passphrase string, coinType uint32, net *chaincfg
 
 // DeriveFromAccount derives child key pair from account extended key by provided BIP44-compliant path
 func (c *Credentials) DeriveFromAccount(path string) (*btcec.PrivateKey, *btcec.PublicKey, error) {
-	parts := strings.Split(path, "/")
-
-	current := c.AccExtKey
-	for _, part := range parts {
-		if part == "m" {
-			continue
-		}
-
-		idx := part
-		hardened := false
-		if strings.Contains(part, hardening) {
-			idx = strings.Replace(part, hardening, "", -1)
-			hardened = true
-		}
-
-		id, err := strconv.ParseUint(idx, 10, 64)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		index := uint32(id)
-		if hardened {
-			index += hdkeychain.HardenedKeyStart
-		}
-
-		child, err := current.Child(index)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		current = child
+	child, err := utils.DerivePath(c.AccExtKey, path)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return toElliptic(current)
+	return toElliptic(child)
 }
 
 func deriveChildren(rootKey *hdkeychain.ExtendedKey, coinType uint32) (*Credentials, error) {
@@ -0,0 +1,21 @@
+package credentials
+
+import (
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// Encrypt serializes root key and encrypts it with passphrase
+func (c *Credentials) Encrypt(passphrase string) ([]byte, error) {
+	return utils.Encrypt([]byte(c.RootKey.String()), passphrase)
+}
+
+// NewFromEncrypted creates new Credentials from root key encrypted with passphrase
+func NewFromEncrypted(cfg *config.Config, data []byte, passphrase string) (*Credentials, error) {
+	privateKey, err := utils.Decrypt(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFromPrivateKey(string(privateKey), cfg.CoinType(), cfg.NetParams())
+}
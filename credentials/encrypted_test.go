@@ -0,0 +1,27 @@
+package credentials
+
+import (
+	"testing"
+
+	"github.com/pavel-main/bws-go/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypted(t *testing.T) {
+	cfg := config.NewPublicTestnet()
+	privateKey := "tprv8ZgxMBicQKsPetcGAZY273DFjDSopBXJNEwFtK7nfCAnAficDoYmTGBRMLHxNoNdpxawo11wnfPoERHbqAcbbn7svZxunP55HPJeNSKoRUZ"
+	credentials, err := NewFromPrivateKey(cfg, privateKey)
+	assert.NoError(t, err, "should create new credentials from private key string")
+
+	data, err := credentials.Encrypt("secret")
+	assert.NoError(t, err, "should encrypt credentials")
+
+	decrypted, err := NewFromEncrypted(cfg, data, "secret")
+	assert.NoError(t, err, "should decrypt credentials")
+	assert.Equal(t, privateKey, decrypted.RootKey.String(), "root keys should match")
+	assert.Equal(t, credentials.AccExtPubKey.String(), decrypted.AccExtPubKey.String(), "account keys should match")
+
+	decrypted, err = NewFromEncrypted(cfg, data, "wrong")
+	assert.Error(t, err, "should fail on wrong passphrase")
+	assert.Nil(t, decrypted, "should return nil credentials")
+}
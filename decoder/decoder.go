@@ -0,0 +1,207 @@
+// Package decoder turns raw transactions and proposals into human-readable structures
+package decoder
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/pavel-main/bws-go/cashaddr"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+const witnessScale = 4
+
+// Params configures address encoding and fee calculation
+type Params struct {
+	Coin     string            // Coin of transaction, BCH addresses are encoded as CashAddr
+	Net      *chaincfg.Params  // Network of addresses
+	Prevouts []*models.TxInput // Coins spent by transaction, if known
+}
+
+// Tx is decoded transaction
+type Tx struct {
+	TxID        string    `json:"txid"`
+	WitnessHash string    `json:"hash"`
+	Version     int32     `json:"version"`
+	LockTime    uint32    `json:"locktime"`
+	Size        int       `json:"size"`
+	VSize       int       `json:"vsize"`
+	Weight      int       `json:"weight"`
+	Inputs      []*Input  `json:"inputs"`
+	Outputs     []*Output `json:"outputs"`
+	OutputTotal int64     `json:"outputTotal"`
+	InputTotal  *int64    `json:"inputTotal,omitempty"` // Known only with all prevouts
+	Fee         *int64    `json:"fee,omitempty"`        // Known only with all prevouts
+	FeePerKb    *int64    `json:"feePerKb,omitempty"`   // Fee per virtual kilobyte
+}
+
+// Input is decoded transaction input
+type Input struct {
+	Outpoint  string   `json:"outpoint"`
+	TxID      string   `json:"txid"`
+	Vout      uint32   `json:"vout"`
+	Sequence  uint32   `json:"sequence"`
+	ScriptSig string   `json:"scriptSig,omitempty"`
+	Witness   []string `json:"witness,omitempty"`
+	Address   string   `json:"address,omitempty"` // Known only with prevout
+	Amount    *int64   `json:"amount,omitempty"`  // Known only with prevout
+}
+
+// Output is decoded transaction output
+type Output struct {
+	Index   int    `json:"index"`
+	Amount  int64  `json:"amount"`
+	Type    string `json:"type"`
+	Script  string `json:"script"`
+	Address string `json:"address,omitempty"`
+	Data    string `json:"data,omitempty"` // Hex-encoded data of OP_RETURN outputs
+}
+
+// Decode decodes hex-encoded raw transaction
+func Decode(rawTx string, params Params) (*Tx, error) {
+	raw, err := utils.ToBytes(rawTx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+
+	return DecodeTx(tx, params)
+}
+
+// DecodeTxProposal decodes unsigned transaction of proposal, its inputs are used as prevouts
+func DecodeTxProposal(txp *models.TxProposal, params Params) (*Tx, error) {
+	tx, err := txp.ToTransaction(params.Net)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Prevouts = append(append([]*models.TxInput{}, params.Prevouts...), txp.Inputs...)
+	return DecodeTx(tx, params)
+}
+
+// DecodeTx decodes transaction
+func DecodeTx(tx *wire.MsgTx, params Params) (*Tx, error) {
+	stripped := tx.SerializeSizeStripped()
+	size := tx.SerializeSize()
+	weight := stripped*(witnessScale-1) + size
+	result := &Tx{
+		TxID:        tx.TxHash().String(),
+		WitnessHash: tx.WitnessHash().String(),
+		Version:     tx.Version,
+		LockTime:    tx.LockTime,
+		Size:        size,
+		Weight:      weight,
+		VSize:       (weight + witnessScale - 1) / witnessScale,
+		Inputs:      []*Input{},
+		Outputs:     []*Output{},
+	}
+
+	prevouts := map[string]*models.TxInput{}
+	for _, prevout := range params.Prevouts {
+		prevouts[prevout.Outpoint()] = prevout
+	}
+
+	var inputTotal int64
+	known := true
+	for _, txIn := range tx.TxIn {
+		input := &Input{
+			TxID:      txIn.PreviousOutPoint.Hash.String(),
+			Vout:      txIn.PreviousOutPoint.Index,
+			Sequence:  txIn.Sequence,
+			ScriptSig: utils.ToHex(txIn.SignatureScript),
+		}
+
+		input.Outpoint = fmt.Sprintf("%s:%d", input.TxID, input.Vout)
+		for _, item := range txIn.Witness {
+			input.Witness = append(input.Witness, utils.ToHex(item))
+		}
+
+		if prevout, ok := prevouts[input.Outpoint]; ok {
+			amount := prevout.Satoshis
+			input.Amount = &amount
+			input.Address = prevout.Address
+			if script, err := utils.ToBytes(prevout.ScriptPubKey); err == nil && len(script) != 0 {
+				input.Address = Address(script, params)
+			}
+
+			inputTotal += amount
+		} else {
+			known = false
+		}
+
+		result.Inputs = append(result.Inputs, input)
+	}
+
+	for idx, txOut := range tx.TxOut {
+		output := &Output{
+			Index:   idx,
+			Amount:  txOut.Value,
+			Type:    txscript.GetScriptClass(txOut.PkScript).String(),
+			Script:  utils.ToHex(txOut.PkScript),
+			Address: Address(txOut.PkScript, params),
+		}
+
+		// Data above BTC relay limit is still standard for BCH
+		if data, err := models.ParseDataScript(txOut.PkScript); err == nil {
+			output.Type = txscript.NullDataTy.String()
+			output.Data = utils.ToHex(data)
+		}
+
+		result.Outputs = append(result.Outputs, output)
+		result.OutputTotal += txOut.Value
+	}
+
+	if known && len(tx.TxIn) != 0 {
+		fee := inputTotal - result.OutputTotal
+		feePerKb := fee * 1000 / int64(result.VSize)
+		result.InputTotal = &inputTotal
+		result.Fee = &fee
+		result.FeePerKb = &feePerKb
+	}
+
+	return result, nil
+}
+
+// Address returns address paid by output script or empty string for non-standard scripts
+func Address(script []byte, params Params) string {
+	_, addresses, _, err := txscript.ExtractPkScriptAddrs(script, params.Net)
+	if err != nil || len(addresses) != 1 {
+		return ""
+	}
+
+	address := addresses[0]
+	if params.Coin != config.CoinBCH {
+		return address.EncodeAddress()
+	}
+
+	prefix, err := cashaddr.Prefix(params.Net)
+	if err != nil {
+		return address.EncodeAddress()
+	}
+
+	var encoded string
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash:
+		encoded, err = cashaddr.Encode(prefix, cashaddr.P2PKH, address.ScriptAddress())
+	case *btcutil.AddressScriptHash:
+		encoded, err = cashaddr.Encode(prefix, cashaddr.P2SH, address.ScriptAddress())
+	default:
+		return address.EncodeAddress()
+	}
+
+	if err != nil {
+		return ""
+	}
+
+	return encoded
+}
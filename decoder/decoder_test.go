@@ -0,0 +1,137 @@
+package decoder
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/pavel-main/bws-go/cashaddr"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/pavel-main/bws-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	net   = &chaincfg.TestNet3Params
+	rawTx = "0100000001b47949a5bd338e1ec86a51b4668714d71997cd5df298275324dcf3d887165e0d0100000000ffffffff02326ab900000000001976a91426e7365e8b0a0bae05e7cfc320f8dc338dfdfa6b88aca4e31d00000000001976a914512c17fd86d596deb44c230ae4a98efae01f013c88ac00000000"
+	spent = &models.TxInput{
+		TxID:     "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4",
+		Vout:     1,
+		Address:  "mgPGbkFGRFMHgWMgsLehxWzN4F1yoEf2Qy",
+		Satoshis: 14110412,
+	}
+)
+
+func TestDecode(t *testing.T) {
+	tx, err := Decode(rawTx, Params{Coin: config.CoinBTC, Net: net})
+	assert.NoError(t, err, "should decode raw transaction")
+	assert.Equal(t, int32(1), tx.Version)
+	assert.Equal(t, uint32(0), tx.LockTime)
+	assert.Equal(t, tx.TxID, tx.WitnessHash, "should have the same hashes without witness")
+	assert.Equal(t, 119, tx.Size)
+	assert.Equal(t, 119, tx.VSize)
+	assert.Equal(t, 476, tx.Weight)
+
+	assert.Len(t, tx.Inputs, 1)
+	assert.Equal(t, spent.Outpoint(), tx.Inputs[0].Outpoint)
+	assert.Equal(t, wire.MaxTxInSequenceNum, tx.Inputs[0].Sequence)
+	assert.Nil(t, tx.Inputs[0].Amount, "should not know amount without prevout")
+	assert.Nil(t, tx.Fee, "should not know fee without prevouts")
+
+	assert.Len(t, tx.Outputs, 2)
+	assert.Equal(t, "mj4exG7YrSTxvpvXyFapoVRjNn9hMvYG1C", tx.Outputs[0].Address)
+	assert.Equal(t, "pubkeyhash", tx.Outputs[0].Type)
+	assert.Equal(t, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", tx.Outputs[1].Address)
+	assert.Equal(t, int64(1958820), tx.Outputs[1].Amount)
+
+	tx, err = Decode(rawTx, Params{Coin: config.CoinBTC, Net: net, Prevouts: []*models.TxInput{spent}})
+	assert.NoError(t, err, "should decode raw transaction with prevouts")
+	assert.Equal(t, int64(14110412), *tx.Inputs[0].Amount)
+	assert.Equal(t, spent.Address, tx.Inputs[0].Address)
+	assert.Equal(t, int64(246), *tx.Fee, "should calculate fee")
+	assert.Equal(t, int64(2067), *tx.FeePerKb)
+
+	_, err = Decode("0100", Params{Net: net})
+	assert.Error(t, err, "should reject truncated transaction")
+
+	_, err = Decode("zz", Params{Net: net})
+	assert.Error(t, err, "should reject invalid hex")
+}
+
+func TestDecodeCashAddr(t *testing.T) {
+	tx, err := Decode(rawTx, Params{Coin: config.CoinBCH, Net: net})
+	assert.NoError(t, err, "should decode raw transaction")
+	assert.Regexp(t, "^bchtest:q", tx.Outputs[0].Address, "should encode CashAddr")
+
+	legacy, _ := btcutil.DecodeAddress("mj4exG7YrSTxvpvXyFapoVRjNn9hMvYG1C", net)
+	address, err := cashaddr.Decode(tx.Outputs[0].Address, cashaddr.PrefixTestNet)
+	assert.NoError(t, err, "should encode valid CashAddr")
+	assert.Equal(t, legacy.ScriptAddress(), address.Hash, "should encode the same hash")
+}
+
+func TestDecodeWitness(t *testing.T) {
+	hash := btcutil.Hash160([]byte("key"))
+	address, _ := btcutil.NewAddressWitnessPubKeyHash(hash, net)
+	script, _ := txscript.PayToAddrScript(address)
+
+	prevHash, _ := chainhash.NewHashFromStr(spent.TxID)
+	msgTx := wire.NewMsgTx(2)
+	msgTx.LockTime = 1500000
+	txIn := wire.NewTxIn(wire.NewOutPoint(prevHash, 1), nil, wire.TxWitness{bytes.Repeat([]byte{1}, 72), bytes.Repeat([]byte{2}, 33)})
+	txIn.Sequence = models.RBFSequence
+	msgTx.AddTxIn(txIn)
+	msgTx.AddTxOut(wire.NewTxOut(10000, script))
+
+	buffer := bytes.NewBuffer([]byte{})
+	msgTx.Serialize(buffer)
+
+	tx, err := Decode(utils.ToHex(buffer.Bytes()), Params{Coin: config.CoinBTC, Net: net})
+	assert.NoError(t, err, "should decode witness transaction")
+	assert.NotEqual(t, tx.TxID, tx.WitnessHash, "should differ by witness")
+	assert.Equal(t, int32(2), tx.Version)
+	assert.Equal(t, uint32(1500000), tx.LockTime)
+	assert.Equal(t, models.RBFSequence, tx.Inputs[0].Sequence)
+	assert.Len(t, tx.Inputs[0].Witness, 2)
+	assert.True(t, tx.VSize < tx.Size, "should discount witness")
+	assert.Equal(t, address.EncodeAddress(), tx.Outputs[0].Address, "should encode bech32 address")
+	assert.Equal(t, "witness_v0_keyhash", tx.Outputs[0].Type)
+}
+
+func TestDecodeTxProposal(t *testing.T) {
+	txp := &models.TxProposal{
+		Amount:        1958820,
+		Fee:           246,
+		OutputOrder:   []int{1, 0},
+		Inputs:        []*models.TxInput{spent},
+		Outputs:       models.NewTxOutputSingle(1958820, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		ChangeAddress: &models.Address{Address: "mj4exG7YrSTxvpvXyFapoVRjNn9hMvYG1C"},
+	}
+
+	tx, err := DecodeTxProposal(txp, Params{Coin: config.CoinBTC, Net: net})
+	assert.NoError(t, err, "should decode proposal")
+	assert.Equal(t, int64(246), *tx.Fee, "should use proposal inputs as prevouts")
+	assert.Equal(t, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY", tx.Outputs[1].Address)
+}
+
+func TestDecodeData(t *testing.T) {
+	data, _ := models.NewDataOutput([]byte("hello"))
+	txp := &models.TxProposal{
+		Amount:      14110166,
+		Fee:         246,
+		OutputOrder: []int{1, 0},
+		Inputs:      []*models.TxInput{spent},
+		Outputs:     append(models.NewTxOutputSingle(14110166, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"), data),
+	}
+
+	tx, err := DecodeTxProposal(txp, Params{Coin: config.CoinBTC, Net: net})
+	assert.NoError(t, err, "should decode proposal with data output")
+	assert.Equal(t, "nulldata", tx.Outputs[0].Type)
+	assert.Equal(t, "68656c6c6f", tx.Outputs[0].Data, "should decode output data")
+	assert.Empty(t, tx.Outputs[0].Address)
+	assert.Empty(t, tx.Outputs[1].Data)
+}
@@ -0,0 +1,270 @@
+// Package fees estimates transaction sizes and fees locally, without dry-run proposals
+package fees
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pavel-main/bws-go/coinselect"
+	"github.com/pavel-main/bws-go/models"
+)
+
+// Address types used by BWS wallets
+const (
+	AddressP2PKH  = models.AddressTypeP2PKH
+	AddressP2SH   = models.AddressTypeP2SH
+	AddressP2WPKH = models.AddressTypeP2WPKH
+	AddressP2WSH  = models.AddressTypeP2WSH
+)
+
+const (
+	overheadSize   = 10 // Version, locktime and input and output counts
+	segwitOverhead = 2  // Marker and flag, witness data only
+	outpointSize   = 36 // Previous tx hash and output index
+	sequenceSize   = 4
+	signatureSize  = 72 // DER signature with sighash type
+	pubKeySize     = 33 // Compressed public key
+	witnessScale   = 4
+)
+
+// Estimator calculates sizes of transactions spending wallet coins
+type Estimator struct {
+	M           int
+	N           int
+	AddressType string
+}
+
+// NewEstimator creates estimator for wallet
+func NewEstimator(wallet *models.Wallet) (*Estimator, error) {
+	return New(int(wallet.M), int(wallet.N), wallet.EffectiveAddressType())
+}
+
+// New creates estimator for m-of-n wallet with given address type
+func New(m, n int, addressType string) (*Estimator, error) {
+	if m < 1 || n < m {
+		return nil, fmt.Errorf("Invalid wallet %d-of-%d", m, n)
+	}
+
+	switch addressType {
+	case AddressP2PKH, AddressP2WPKH:
+		if n != 1 {
+			return nil, fmt.Errorf("Address type %s requires single copayer", addressType)
+		}
+	case AddressP2SH, AddressP2WSH:
+	default:
+		return nil, fmt.Errorf("Unknown address type: %s", addressType)
+	}
+
+	return &Estimator{M: m, N: n, AddressType: addressType}, nil
+}
+
+// Segwit reports whether wallet inputs carry witness data
+func (e *Estimator) Segwit() bool {
+	return e.AddressType == AddressP2WPKH || e.AddressType == AddressP2WSH
+}
+
+// redeemScriptSize returns size of m-of-n multisig script
+func (e *Estimator) redeemScriptSize() int {
+	return 3 + e.N*(1+pubKeySize)
+}
+
+// InputWeight returns weight units of single wallet input
+func (e *Estimator) InputWeight() int {
+	switch e.AddressType {
+	case AddressP2PKH:
+		script := 1 + signatureSize + 1 + pubKeySize
+		return (outpointSize + varIntSize(script) + script + sequenceSize) * witnessScale
+	case AddressP2SH:
+		redeem := e.redeemScriptSize()
+		script := 1 + e.M*(1+signatureSize) + pushSize(redeem) + redeem
+		return (outpointSize + varIntSize(script) + script + sequenceSize) * witnessScale
+	case AddressP2WPKH:
+		witness := 1 + 1 + signatureSize + 1 + pubKeySize
+		return (outpointSize+1+sequenceSize)*witnessScale + witness
+	case AddressP2WSH:
+		redeem := e.redeemScriptSize()
+		witness := varIntSize(e.M+2) + 1 + e.M*(1+signatureSize) + varIntSize(redeem) + redeem
+		return (outpointSize+1+sequenceSize)*witnessScale + witness
+	}
+
+	return 0
+}
+
+// InputSize returns virtual size of single wallet input, rounded up
+func (e *Estimator) InputSize() int {
+	return (e.InputWeight() + witnessScale - 1) / witnessScale
+}
+
+// OutputSize returns size of wallet (change) output
+func (e *Estimator) OutputSize() int {
+	return OutputSize(e.AddressType)
+}
+
+// Weight returns weight units of transaction with wallet inputs and outputs
+func (e *Estimator) Weight(inputs, outputs int) int {
+	return e.weight(inputs, outputs*e.OutputSize())
+}
+
+// PaymentVSize returns virtual size of transaction with wallet inputs paying outputs of given sizes,
+// with wallet change output when change is set
+func (e *Estimator) PaymentVSize(inputs int, outputSizes []int, change bool) int {
+	size := sum(outputSizes)
+	if change {
+		size += e.OutputSize()
+	}
+
+	return (e.weight(inputs, size) + witnessScale - 1) / witnessScale
+}
+
+// weight returns weight units of transaction with wallet inputs and outputs of given total size
+func (e *Estimator) weight(inputs, outputsSize int) int {
+	weight := (overheadSize+outputsSize)*witnessScale + inputs*e.InputWeight()
+	if e.Segwit() && inputs > 0 {
+		weight += segwitOverhead
+	}
+
+	return weight
+}
+
+// VSize returns virtual size of transaction with wallet inputs and outputs, equals size for legacy wallets
+func (e *Estimator) VSize(inputs, outputs int) int {
+	return (e.Weight(inputs, outputs) + witnessScale - 1) / witnessScale
+}
+
+// Size returns serialized size of transaction including witness data
+func (e *Estimator) Size(inputs, outputs int) int {
+	weight := e.Weight(inputs, outputs)
+	if !e.Segwit() {
+		return weight / witnessScale
+	}
+
+	// Witness bytes count once, the rest is scaled
+	witness := inputs*(e.InputWeight()-(outpointSize+1+sequenceSize)*witnessScale) + segwitOverhead
+	return (weight-witness)/witnessScale + witness
+}
+
+// Fee returns expected fee of transaction with wallet inputs and outputs
+func (e *Estimator) Fee(inputs, outputs int, feePerKb uint64) int64 {
+	return Fee(e.VSize(inputs, outputs), feePerKb)
+}
+
+// Params returns coin selection params for wallet paying recipients of own address type
+func (e *Estimator) Params(target int64, feePerKb uint64, outputs int) coinselect.Params {
+	outputSizes := make([]int, outputs)
+	for i := range outputSizes {
+		outputSizes[i] = e.OutputSize()
+	}
+
+	return e.PaymentParams(target, feePerKb, outputSizes)
+}
+
+// PaymentParams returns coin selection params for wallet paying outputs of given sizes
+func (e *Estimator) PaymentParams(target int64, feePerKb uint64, outputSizes []int) coinselect.Params {
+	params := coinselect.DefaultParams(target, feePerKb, len(outputSizes))
+	params.BaseSize = e.PaymentVSize(0, outputSizes, false)
+	params.InputSize = e.InputSize()
+	params.ChangeSize = e.OutputSize()
+	if e.Segwit() {
+		params.BaseSize++ // Marker and flag
+	}
+
+	return params
+}
+
+// Quote represents fee expected at fee level
+type Quote struct {
+	Level     string `json:"level"`
+	FeePerKb  uint   `json:"feePerKb"`
+	NumBlocks uint   `json:"nbBlocks"`
+	Fee       int64  `json:"fee"`
+}
+
+// Quote returns fees of transaction with wallet inputs and outputs at every fee level
+func (e *Estimator) Quote(levels []*models.FeeLevel, inputs, outputs int) []*Quote {
+	vsize := e.VSize(inputs, outputs)
+	quotes := []*Quote{}
+	for _, level := range levels {
+		quotes = append(quotes, &Quote{
+			Level:     level.Level,
+			FeePerKb:  level.FeePerKb,
+			NumBlocks: level.NumBlocks,
+			Fee:       Fee(vsize, uint64(level.FeePerKb)),
+		})
+	}
+
+	return quotes
+}
+
+// Fee converts fee rate per kilobyte into fee of transaction with given virtual size, rounded up
+func Fee(vsize int, feePerKb uint64) int64 {
+	return int64((uint64(vsize)*feePerKb + 999) / 1000)
+}
+
+// OutputSize returns size of output paying to address type
+func OutputSize(addressType string) int {
+	switch addressType {
+	case AddressP2SH:
+		return 8 + 1 + 23
+	case AddressP2WPKH:
+		return 8 + 1 + 22
+	case AddressP2WSH:
+		return 8 + 1 + 34
+	}
+
+	return 8 + 1 + 25
+}
+
+// ScriptOutputSize returns size of output with given script
+func ScriptOutputSize(script []byte) int {
+	return 8 + varIntSize(len(script)) + len(script)
+}
+
+// OutputSizes returns sizes of proposal outputs of coin, derived from their scripts
+func OutputSizes(outputs []*models.TxOutput, coin string, net *chaincfg.Params) ([]int, error) {
+	sizes := []int{}
+	for _, output := range outputs {
+		script, err := output.PkScript(coin, net)
+		if err != nil {
+			return nil, err
+		}
+
+		sizes = append(sizes, ScriptOutputSize(script))
+	}
+
+	return sizes, nil
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+
+	return total
+}
+
+// varIntSize returns size of compact size prefix
+func varIntSize(n int) int {
+	switch {
+	case n < 0xfd:
+		return 1
+	case n <= 0xffff:
+		return 3
+	}
+
+	return 5
+}
+
+// pushSize returns size of push opcode for data of given length
+func pushSize(n int) int {
+	switch {
+	case n < 76:
+		return 1
+	case n <= 0xff:
+		return 2
+	case n <= 0xffff:
+		return 3
+	}
+
+	return 5
+}
@@ -0,0 +1,98 @@
+package fees
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimatorSizes(t *testing.T) {
+	scenarios := []struct {
+		M, N        int
+		AddressType string
+		InputSize   int
+		OutputSize  int
+		VSize       int // One input, two outputs
+		Size        int
+	}{
+		{1, 1, AddressP2PKH, 148, 34, 226, 226},
+		{2, 3, AddressP2SH, 297, 32, 371, 371},
+		{1, 1, AddressP2WPKH, 68, 31, 141, 223},
+		{2, 3, AddressP2WSH, 105, 43, 201, 393},
+	}
+
+	for _, s := range scenarios {
+		e, err := New(s.M, s.N, s.AddressType)
+		assert.NoError(t, err, "should create estimator")
+		assert.Equal(t, s.InputSize, e.InputSize(), "%s input size should match", s.AddressType)
+		assert.Equal(t, s.OutputSize, e.OutputSize(), "%s output size should match", s.AddressType)
+		assert.Equal(t, s.VSize, e.VSize(1, 2), "%s vsize should match", s.AddressType)
+		assert.Equal(t, s.Size, e.Size(1, 2), "%s size should match", s.AddressType)
+	}
+}
+
+func TestNewEstimator(t *testing.T) {
+	e, err := NewEstimator(&models.Wallet{M: 2, N: 3})
+	assert.NoError(t, err, "should default to P2SH for multisig wallets")
+	assert.Equal(t, AddressP2SH, e.AddressType)
+
+	e, err = NewEstimator(&models.Wallet{M: 1, N: 1, AddressType: "P2WPKH"})
+	assert.NoError(t, err)
+	assert.True(t, e.Segwit(), "should detect segwit wallet")
+
+	invalid := []*models.Wallet{
+		{M: 0, N: 1},
+		{M: 3, N: 2},
+		{M: 1, N: 2, AddressType: AddressP2PKH},
+		{M: 1, N: 1, AddressType: "P2TR"},
+	}
+
+	for _, wallet := range invalid {
+		_, err := NewEstimator(wallet)
+		assert.Error(t, err, "should reject invalid wallet")
+	}
+}
+
+func TestFee(t *testing.T) {
+	assert.Equal(t, int64(226), Fee(226, 1000), "should convert fee per KB")
+	assert.Equal(t, int64(1), Fee(1, 1), "should round up")
+	assert.Equal(t, int64(0), Fee(226, 0))
+
+	e, _ := New(1, 1, AddressP2PKH)
+	quotes := e.Quote([]*models.FeeLevel{{Level: "normal", FeePerKb: 20000, NumBlocks: 2}, {Level: "economy", FeePerKb: 5000}}, 1, 2)
+	assert.Equal(t, []*Quote{
+		{Level: "normal", FeePerKb: 20000, NumBlocks: 2, Fee: 4520},
+		{Level: "economy", FeePerKb: 5000, Fee: 1130},
+	}, quotes, "should quote every fee level")
+	assert.Equal(t, int64(4520), e.Fee(1, 2, 20000))
+}
+
+func TestParams(t *testing.T) {
+	e, _ := New(2, 3, AddressP2WSH)
+	params := e.Params(100000, 1000, 1)
+	assert.Equal(t, 10+43+1, params.BaseSize, "should include marker and flag")
+	assert.Equal(t, 105, params.InputSize)
+	assert.Equal(t, 43, params.ChangeSize)
+	assert.Equal(t, int64(100000), params.Target)
+}
+
+func TestPaymentVSize(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	e, _ := New(1, 1, AddressP2WPKH)
+	data, _ := models.NewDataOutput([]byte("hello"))
+	outputs := []*models.TxOutput{models.NewTxOutput(1000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"), data}
+	sizes, err := OutputSizes(outputs, config.CoinBTC, net)
+	assert.NoError(t, err, "should size outputs from scripts")
+	assert.Equal(t, []int{34, 16}, sizes, "should size P2PKH recipient and data output")
+
+	assert.Equal(t, e.VSize(1, 2)+34-e.OutputSize(), e.PaymentVSize(1, sizes[:1], true), "should not size P2PKH recipient as wallet output")
+
+	params := e.PaymentParams(100000, 1000, sizes[:1])
+	assert.Equal(t, e.PaymentVSize(0, sizes[:1], false)+1, params.BaseSize, "should include recipient size and marker")
+
+	_, err = OutputSizes([]*models.TxOutput{models.NewTxOutput(1000, "invalid")}, config.CoinBTC, net)
+	assert.Error(t, err, "should reject invalid address")
+}
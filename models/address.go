@@ -1,5 +1,14 @@
 package models
 
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/pavel-main/bws-go/cashaddr"
+	"github.com/pavel-main/bws-go/config"
+)
+
 // Address represents receive address
 type Address struct {
 	Version     string   `json:"version"`
@@ -14,3 +23,45 @@ type Address struct {
 	Type        string   `json:"type"`
 	HasActivity *bool    `json:"hasActivity,omitempty"`
 }
+
+// DecodeAddress decodes address of coin and network, BCH addresses are accepted in CashAddr and legacy format
+func DecodeAddress(address, coin string, net *chaincfg.Params) (btcutil.Address, error) {
+	if coin == config.CoinBCH {
+		prefix, err := cashaddr.Prefix(net)
+		if err != nil {
+			return nil, err
+		}
+
+		if decoded, err := cashaddr.Decode(address, prefix); err == nil {
+			if decoded.Prefix != prefix {
+				return nil, fmt.Errorf("Address %s is not for %s network", address, net.Name)
+			}
+
+			if decoded.Type == cashaddr.P2SH {
+				return btcutil.NewAddressScriptHashFromHash(decoded.Hash, net)
+			}
+
+			return btcutil.NewAddressPubKeyHash(decoded.Hash, net)
+		}
+	}
+
+	decoded, err := btcutil.DecodeAddress(address, net)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid address: %s", address)
+	}
+
+	if !decoded.IsForNet(net) {
+		return nil, fmt.Errorf("Address %s is not for %s network", address, net.Name)
+	}
+
+	// Segwit addresses do not exist on BCH
+	if coin == config.CoinBCH {
+		switch decoded.(type) {
+		case *btcutil.AddressPubKeyHash, *btcutil.AddressScriptHash:
+		default:
+			return nil, fmt.Errorf("Address %s is not supported by BCH", address)
+		}
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// requestKeyAuthPath derives copayer key authorizing additional request keys
+const requestKeyAuthPath = "m/2"
+
+// RequestPubKey represents key used by copayer to sign BWS requests
+type RequestPubKey struct {
+	Key       string `json:"key"`
+	Signature string `json:"signature"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Copayer represents wallet copayer
+type Copayer struct {
+	ID             string           `json:"id"`
+	Name           string           `json:"name"`
+	XPubKey        string           `json:"xPubKey"`
+	RequestPubKey  string           `json:"requestPubKey"`
+	RequestPubKeys []*RequestPubKey `json:"requestPubKeys"`
+	Signature      string           `json:"signature"` // Signature of name, XPub and request key by wallet invitation key
+	CreatedOn      uint             `json:"createdOn"`
+	CustomData     string           `json:"customData,omitempty"`
+}
+
+// hash returns message signed by wallet invitation key when copayer joins
+func (c *Copayer) hash() []byte {
+	return []byte(strings.Join([]string{c.Name, c.XPubKey, c.RequestPubKey}, "|"))
+}
+
+// invited reports whether copayer joined using invitation of wallet with public key
+func (c *Copayer) invited(walletPubKey *btcec.PublicKey) bool {
+	signature, err := utils.ToBytes(c.Signature)
+	if err != nil {
+		return false
+	}
+
+	valid, err := utils.VerifyMessage(c.hash(), signature, walletPubKey)
+	return err == nil && valid
+}
+
+// authorizes reports whether request key was signed by key derived from copayer XPub
+func (c *Copayer) authorizes(key, signature string) bool {
+	xpub, err := hdkeychain.NewKeyFromString(c.XPubKey)
+	if err != nil {
+		return false
+	}
+
+	child, err := utils.DerivePath(xpub, requestKeyAuthPath)
+	if err != nil {
+		return false
+	}
+
+	pubKey, err := child.ECPubKey()
+	if err != nil {
+		return false
+	}
+
+	bytes, err := utils.ToBytes(signature)
+	if err != nil {
+		return false
+	}
+
+	valid, err := utils.VerifyMessage([]byte(key), bytes, pubKey)
+	return err == nil && valid
+}
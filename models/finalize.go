@@ -0,0 +1,259 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// InputError describes failure of single transaction input
+type InputError struct {
+	Index    int
+	Outpoint string
+	Err      error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("Input %d (%s): %v", e.Index, e.Outpoint, e.Err)
+}
+
+// VerificationError lists failed inputs of transaction
+type VerificationError struct {
+	Inputs []*InputError
+}
+
+func (e *VerificationError) Error() string {
+	messages := []string{}
+	for _, input := range e.Inputs {
+		messages = append(messages, input.Error())
+	}
+
+	return "Transaction verification failed: " + strings.Join(messages, "; ")
+}
+
+// inputSignature is copayer signature of single input
+type inputSignature struct {
+	pubKey    []byte // Compressed public key of signer
+	signature []byte // DER signature with sighash type
+}
+
+// Finalize assembles fully signed transaction from signatures of accepting copayers
+// and verifies every input with script engine
+func (txp *TxProposal) Finalize(net *chaincfg.Params) (*wire.MsgTx, error) {
+	if txp.Coin == config.CoinBCH {
+		return nil, errors.New("Local finalization of BCH transactions is not supported")
+	}
+
+	tx, err := txp.ToTransaction(net)
+	if err != nil {
+		return nil, err
+	}
+
+	addressType := txp.addressType()
+	for idx, input := range txp.Inputs {
+		signatures, err := txp.inputSignatures(idx)
+		if err != nil {
+			return nil, err
+		}
+
+		switch addressType {
+		case AddressTypeP2PKH, AddressTypeP2WPKH:
+			signer := signatures[0]
+			if addressType == AddressTypeP2WPKH {
+				tx.TxIn[idx].SignatureScript = nil
+				tx.TxIn[idx].Witness = wire.TxWitness{signer.signature, signer.pubKey}
+				continue
+			}
+
+			script, err := txscript.NewScriptBuilder().AddData(signer.signature).AddData(signer.pubKey).Script()
+			if err != nil {
+				return nil, err
+			}
+
+			tx.TxIn[idx].SignatureScript = script
+		case AddressTypeP2SH, AddressTypeP2WSH:
+			redeemScript, err := txp.BuildRedeemScript(input, net)
+			if err != nil {
+				return nil, err
+			}
+
+			ordered, err := txp.orderSignatures(input, signatures)
+			if err != nil {
+				return nil, &InputError{Index: idx, Outpoint: input.Outpoint(), Err: err}
+			}
+
+			if addressType == AddressTypeP2WSH {
+				witness := wire.TxWitness{nil}
+				witness = append(witness, ordered...)
+				tx.TxIn[idx].SignatureScript = nil
+				tx.TxIn[idx].Witness = append(witness, redeemScript)
+				continue
+			}
+
+			// Extra OP_0 is consumed by off-by-one bug of OP_CHECKMULTISIG
+			builder := txscript.NewScriptBuilder().AddOp(txscript.OP_0)
+			for _, signature := range ordered {
+				builder.AddData(signature)
+			}
+
+			script, err := builder.AddData(redeemScript).Script()
+			if err != nil {
+				return nil, err
+			}
+
+			tx.TxIn[idx].SignatureScript = script
+		default:
+			return nil, fmt.Errorf("Unknown address type: %s", addressType)
+		}
+	}
+
+	if err := txp.VerifyTransaction(tx, net); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// addressType returns address type of proposal inputs
+func (txp *TxProposal) addressType() string {
+	if txp.AddressType != "" {
+		return txp.AddressType
+	}
+
+	return defaultAddressType(txp.WalletN)
+}
+
+// inputSignatures collects signatures of input from accept actions
+func (txp *TxProposal) inputSignatures(idx int) ([]*inputSignature, error) {
+	input := txp.Inputs[idx]
+	result := []*inputSignature{}
+	for _, action := range txp.Actions {
+		if action.Type != ActionAccept {
+			continue
+		}
+
+		if len(action.Signatures) != len(txp.Inputs) {
+			return nil, fmt.Errorf("Copayer %s signed %d of %d inputs", action.CopayerID, len(action.Signatures), len(txp.Inputs))
+		}
+
+		signature, err := utils.ToBytes(action.Signatures[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		pubKey, err := action.PublicKey(input.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &inputSignature{
+			pubKey:    pubKey.SerializeCompressed(),
+			signature: append(signature, byte(txscript.SigHashAll)),
+		})
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("Proposal has no signatures")
+	}
+
+	return result, nil
+}
+
+// orderSignatures returns required number of signatures in order of redeem script keys
+func (txp *TxProposal) orderSignatures(input *TxInput, signatures []*inputSignature) ([][]byte, error) {
+	keys := append([]string{}, input.PublicKeys...)
+	sort.Strings(keys)
+
+	positions := map[string]int{}
+	for position, key := range keys {
+		positions[key] = position
+	}
+
+	byPosition := map[int][]byte{}
+	for _, signer := range signatures {
+		position, ok := positions[utils.ToHex(signer.pubKey)]
+		if !ok {
+			return nil, fmt.Errorf("Signer key %s does not belong to input", utils.ToHex(signer.pubKey))
+		}
+
+		byPosition[position] = signer.signature
+	}
+
+	if len(byPosition) < txp.WalletM {
+		return nil, fmt.Errorf("Only %d of %d required signatures", len(byPosition), txp.WalletM)
+	}
+
+	ordered := [][]byte{}
+	for position := range keys {
+		if signature, ok := byPosition[position]; ok && len(ordered) < txp.WalletM {
+			ordered = append(ordered, signature)
+		}
+	}
+
+	return ordered, nil
+}
+
+// prevScript returns output script spent by input
+func (txp *TxProposal) prevScript(input *TxInput, net *chaincfg.Params) ([]byte, error) {
+	if input.ScriptPubKey != "" {
+		return utils.ToBytes(input.ScriptPubKey)
+	}
+
+	address, err := DecodeAddress(input.Address, txp.Coin, net)
+	if err != nil {
+		return nil, err
+	}
+
+	return txscript.PayToAddrScript(address)
+}
+
+// VerifyTransaction checks that signed transaction spends proposal inputs
+// and executes script of every input against its prevout script and amount
+func (txp *TxProposal) VerifyTransaction(tx *wire.MsgTx, net *chaincfg.Params) error {
+	if len(tx.TxIn) != len(txp.Inputs) {
+		return fmt.Errorf("Transaction has %d inputs, proposal %d", len(tx.TxIn), len(txp.Inputs))
+	}
+
+	// Every input is checked, so all failures are reported at once
+	failed := []*InputError{}
+	hashes := txscript.NewTxSigHashes(tx)
+	for idx, input := range txp.Inputs {
+		if err := txp.verifyInput(tx, hashes, idx, net); err != nil {
+			failed = append(failed, &InputError{Index: idx, Outpoint: input.Outpoint(), Err: err})
+		}
+	}
+
+	if len(failed) != 0 {
+		return &VerificationError{Inputs: failed}
+	}
+
+	return nil
+}
+
+// verifyInput executes script of single input
+func (txp *TxProposal) verifyInput(tx *wire.MsgTx, hashes *txscript.TxSigHashes, idx int, net *chaincfg.Params) error {
+	input := txp.Inputs[idx]
+	prevOut := tx.TxIn[idx].PreviousOutPoint
+	if prevOut.Hash.String() != input.TxID || prevOut.Index != input.Vout {
+		return fmt.Errorf("spends %s instead", prevOut)
+	}
+
+	pkScript, err := txp.prevScript(input, net)
+	if err != nil {
+		return err
+	}
+
+	engine, err := txscript.NewEngine(pkScript, tx, idx, txscript.StandardVerifyFlags, nil, hashes, input.Satoshis)
+	if err != nil {
+		return err
+	}
+
+	return engine.Execute()
+}
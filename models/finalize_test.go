@@ -0,0 +1,204 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/pavel-main/bws-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockCopayer is account key of wallet copayer
+type mockCopayer struct {
+	id  string
+	key *hdkeychain.ExtendedKey
+}
+
+func newMockCopayers(n int) []*mockCopayer {
+	copayers := []*mockCopayer{}
+	for i := 0; i < n; i++ {
+		key, _ := hdkeychain.NewMaster(bytes.Repeat([]byte{byte(i + 1)}, 32), net)
+		copayers = append(copayers, &mockCopayer{id: string(rune('a' + i)), key: key})
+	}
+
+	return copayers
+}
+
+func (c *mockCopayer) xpub() string {
+	public, _ := c.key.Neuter()
+	return public.String()
+}
+
+func (c *mockCopayer) keys(path string) (*btcec.PrivateKey, *btcec.PublicKey) {
+	child, _ := utils.DerivePath(c.key, path)
+	private, _ := child.ECPrivKey()
+	public, _ := child.ECPubKey()
+	return private, public
+}
+
+// mockMultisigInput returns input of m-of-n P2SH address at path
+func mockMultisigInput(copayers []*mockCopayer, m int, path, txid string, satoshis int64) *TxInput {
+	input := &TxInput{TxID: txid, Path: path, Satoshis: satoshis}
+	for _, copayer := range copayers {
+		_, public := copayer.keys(path)
+		input.PublicKeys = append(input.PublicKeys, utils.ToHex(public.SerializeCompressed()))
+	}
+
+	redeemScript, _ := (&TxProposal{WalletM: m}).BuildRedeemScript(input, net)
+	address, _ := btcutil.NewAddressScriptHash(redeemScript, net)
+	script, _ := txscript.PayToAddrScript(address)
+	input.Address = address.EncodeAddress()
+	input.ScriptPubKey = utils.ToHex(script)
+	return input
+}
+
+func mockUnsignedTxp(m, n int, inputs ...*TxInput) *TxProposal {
+	var total int64
+	for _, input := range inputs {
+		total += input.Satoshis
+	}
+
+	return &TxProposal{
+		WalletM:       m,
+		WalletN:       n,
+		Amount:        total / 2,
+		Fee:           1000,
+		OutputOrder:   []int{0, 1},
+		Inputs:        inputs,
+		Outputs:       NewTxOutputSingle(total/2, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+		ChangeAddress: &Address{Address: "mykbw8QcyMq9MeonF8626ayQYq4DVtiisK"},
+	}
+}
+
+// accept signs every input of proposal as copayer
+func accept(t *testing.T, txp *TxProposal, copayer *mockCopayer) {
+	action := &TxAction{Type: ActionAccept, CopayerID: copayer.id, XPub: copayer.xpub()}
+	for idx, input := range txp.Inputs {
+		private, _ := copayer.keys(input.Path)
+		signature, err := txp.InputSignature(private, net, idx)
+		assert.NoError(t, err, "should sign input")
+		action.Signatures = append(action.Signatures, utils.ToHex(signature))
+	}
+
+	txp.Actions = append(txp.Actions, action)
+}
+
+func TestFinalizeMultisig(t *testing.T) {
+	copayers := newMockCopayers(3)
+	input := mockMultisigInput(copayers, 2, "m/0/0", "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", 100000)
+	txp := mockUnsignedTxp(2, 3, input)
+
+	accept(t, txp, copayers[2])
+	_, err := txp.Finalize(net)
+	assert.Error(t, err, "should require enough signatures")
+
+	txp.Actions = append(txp.Actions, &TxAction{Type: ActionReject, CopayerID: "b"})
+	accept(t, txp, copayers[0])
+	tx, err := txp.Finalize(net)
+	assert.NoError(t, err, "should finalize transaction signed by 2 of 3 copayers")
+	pushes, _ := txscript.PushedData(tx.TxIn[0].SignatureScript)
+	assert.Len(t, pushes, 4, "should contain dummy, two signatures and redeem script")
+
+	// Signature of key outside of redeem script
+	txp.Actions[0].XPub = newMockCopayers(4)[3].xpub()
+	_, err = txp.Finalize(net)
+	assert.Error(t, err, "should reject signer outside of wallet")
+
+	// Signature made by other copayer
+	txp.Actions[0].XPub = copayers[2].xpub()
+	txp.Actions[0].Signatures[0] = txp.Actions[2].Signatures[0]
+	_, err = txp.Finalize(net)
+	assert.Error(t, err, "should reject invalid signature")
+}
+
+func TestFinalizeSingleSig(t *testing.T) {
+	copayer := newMockCopayers(1)[0]
+	_, public := copayer.keys("m/0/3")
+	address, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(public.SerializeCompressed()), net)
+	script, _ := txscript.PayToAddrScript(address)
+
+	input := &TxInput{
+		TxID:         "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4",
+		Vout:         1,
+		Path:         "m/0/3",
+		Address:      address.EncodeAddress(),
+		ScriptPubKey: utils.ToHex(script),
+		Satoshis:     50000,
+	}
+
+	txp := mockUnsignedTxp(1, 1, input)
+	_, err := txp.Finalize(net)
+	assert.Error(t, err, "should require signatures")
+
+	accept(t, txp, copayer)
+	tx, err := txp.Finalize(net)
+	assert.NoError(t, err, "should finalize single signature transaction")
+	assert.NotEmpty(t, tx.TxIn[0].SignatureScript, "should set script signature")
+
+	txp.Coin = "bch"
+	_, err = txp.Finalize(net)
+	assert.Error(t, err, "should not finalize BCH transaction")
+}
+
+func TestFinalizeWitness(t *testing.T) {
+	copayer := newMockCopayers(1)[0]
+	private, public := copayer.keys("m/0/0")
+	address, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(public.SerializeCompressed()), net)
+	script, _ := txscript.PayToAddrScript(address)
+
+	input := &TxInput{
+		TxID:         "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4",
+		Path:         "m/0/0",
+		ScriptPubKey: utils.ToHex(script),
+		Satoshis:     50000,
+	}
+
+	txp := mockUnsignedTxp(1, 1, input)
+	txp.AddressType = AddressTypeP2WPKH
+	tx, _ := txp.ToTransaction(net)
+	signature, err := txscript.RawTxInWitnessSignature(tx, txscript.NewTxSigHashes(tx), 0, input.Satoshis, script, txscript.SigHashAll, private)
+	assert.NoError(t, err, "should sign witness input")
+
+	accept(t, txp, copayer)
+	assert.Equal(t, utils.ToHex(signature[:len(signature)-1]), txp.Actions[0].Signatures[0], "should sign witness input hash")
+
+	tx, err = txp.Finalize(net)
+	assert.NoError(t, err, "should finalize witness transaction")
+	assert.Empty(t, tx.TxIn[0].SignatureScript, "should not set script signature")
+	assert.Len(t, tx.TxIn[0].Witness, 2, "should set signature and key witness")
+}
+
+func TestVerifyTransaction(t *testing.T) {
+	copayers := newMockCopayers(3)
+	first := mockMultisigInput(copayers, 2, "m/0/0", "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", 60000)
+	second := mockMultisigInput(copayers, 2, "m/0/1", "b53d75d4b45b574d8200c2539b0af761dddc94aa2005047643e2a5a71a695d52", 70000)
+	third := mockMultisigInput(copayers, 2, "m/0/2", "cd123d19e7b85f672213064e78cd868c53e84ef8b5ab079ecead251025b0e170", 80000)
+	txp := mockUnsignedTxp(2, 3, first, second, third)
+	accept(t, txp, copayers[0])
+	accept(t, txp, copayers[1])
+
+	tx, err := txp.Finalize(net)
+	assert.NoError(t, err, "should finalize transaction")
+	assert.NoError(t, txp.VerifyTransaction(tx, net), "should verify signed transaction")
+
+	// Scripts of the last two inputs swapped
+	tx.TxIn[1].SignatureScript, tx.TxIn[2].SignatureScript = tx.TxIn[2].SignatureScript, tx.TxIn[1].SignatureScript
+	err = txp.VerifyTransaction(tx, net)
+	assert.IsType(t, &VerificationError{}, err, "should fail verification")
+	failed := err.(*VerificationError).Inputs
+	assert.Len(t, failed, 2, "should report every failed input")
+	assert.Equal(t, 1, failed[0].Index)
+	assert.Equal(t, third.Outpoint(), failed[1].Outpoint)
+
+	// Prevout script of other address
+	tx, _ = txp.Finalize(net)
+	first.ScriptPubKey = second.ScriptPubKey
+	assert.Error(t, txp.VerifyTransaction(tx, net), "should fail on changed prevout")
+
+	tx.TxIn = tx.TxIn[:2]
+	assert.Error(t, txp.VerifyTransaction(tx, net), "should require all proposal inputs")
+}
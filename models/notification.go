@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // Notification represents... well, notification
 type Notification struct {
 	ID        string                 `json:"id"`
@@ -9,4 +11,6 @@ type Notification struct {
 	CreatedOn int                    `json:"createdOn"`
 	CreatorID *string                `json:"creatorId"`
 	WalletID  string                 `json:"walletId"`
+
+	raw json.RawMessage // Data as received, decoded by Payload
 }
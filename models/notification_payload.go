@@ -0,0 +1,284 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// List of notification types emitted by BWS
+const (
+	NotificationNewCopayer                = "NewCopayer"
+	NotificationWalletComplete            = "WalletComplete"
+	NotificationNewAddress                = "NewAddress"
+	NotificationNewBlock                  = "NewBlock"
+	NotificationNewIncomingTx             = "NewIncomingTx"
+	NotificationNewOutgoingTx             = "NewOutgoingTx"
+	NotificationNewOutgoingTxByThirdParty = "NewOutgoingTxByThirdParty"
+	NotificationNewTxProposal             = "NewTxProposal"
+	NotificationTxProposalAcceptedBy      = "TxProposalAcceptedBy"
+	NotificationTxProposalRejectedBy      = "TxProposalRejectedBy"
+	NotificationTxProposalFinallyAccepted = "TxProposalFinallyAccepted"
+	NotificationTxProposalFinallyRejected = "TxProposalFinallyRejected"
+	NotificationTxProposalRemoved         = "TxProposalRemoved"
+	NotificationTxConfirmation            = "TxConfirmation"
+	NotificationScanFinished              = "ScanFinished"
+)
+
+// NotificationPayload is implemented by typed notification data structures
+type NotificationPayload interface {
+	NotificationType() string
+}
+
+// NewCopayerPayload represents copayer joining the wallet
+type NewCopayerPayload struct {
+	WalletID    string `json:"walletId"`
+	CopayerID   string `json:"copayerId"`
+	CopayerName string `json:"copayerName"`
+}
+
+// WalletCompletePayload represents all copayers joined the wallet
+type WalletCompletePayload struct {
+	WalletID string `json:"walletId"`
+}
+
+// NewAddressPayload represents address created by a copayer
+type NewAddressPayload struct {
+	Address string `json:"address"`
+}
+
+// NewBlockPayload represents new block on the wallet network
+type NewBlockPayload struct {
+	Hash    string `json:"hash"`
+	Coin    string `json:"coin"`
+	Network string `json:"network"`
+}
+
+// NewIncomingTxPayload represents funds received by the wallet
+type NewIncomingTxPayload struct {
+	TxID         string `json:"txid"`
+	Address      string `json:"address"`
+	Amount       int64  `json:"amount"`
+	TokenAddress string `json:"tokenAddress,omitempty"`
+}
+
+// NewOutgoingTxPayload represents broadcast of wallet transaction proposal
+type NewOutgoingTxPayload struct {
+	TxProposalID string `json:"txProposalId"`
+	TxID         string `json:"txid"`
+	Amount       int64  `json:"amount"`
+}
+
+// NewOutgoingTxByThirdPartyPayload represents proposal broadcast outside of BWS
+type NewOutgoingTxByThirdPartyPayload struct {
+	TxProposalID string `json:"txProposalId"`
+	TxID         string `json:"txid"`
+}
+
+// NewTxProposalPayload represents published transaction proposal
+type NewTxProposalPayload struct {
+	TxProposalID string  `json:"txProposalId"`
+	Amount       int64   `json:"amount"`
+	Message      *string `json:"message"`
+}
+
+// TxProposalAcceptedByPayload represents copayer signing the proposal
+type TxProposalAcceptedByPayload struct {
+	TxProposalID string `json:"txProposalId"`
+	CopayerID    string `json:"copayerId"`
+}
+
+// TxProposalRejectedByPayload represents copayer rejecting the proposal
+type TxProposalRejectedByPayload struct {
+	TxProposalID string `json:"txProposalId"`
+	CopayerID    string `json:"copayerId"`
+}
+
+// TxProposalFinallyAcceptedPayload represents proposal collected enough signatures
+type TxProposalFinallyAcceptedPayload struct {
+	TxProposalID string `json:"txProposalId"`
+}
+
+// TxProposalFinallyRejectedPayload represents proposal collected enough rejections
+type TxProposalFinallyRejectedPayload struct {
+	TxProposalID string `json:"txProposalId"`
+}
+
+// TxProposalRemovedPayload represents deleted proposal
+type TxProposalRemovedPayload struct {
+	TxProposalID string `json:"txProposalId"`
+}
+
+// TxConfirmationPayload represents confirmation of subscribed transaction
+type TxConfirmationPayload struct {
+	TxID    string `json:"txid"`
+	Amount  int64  `json:"amount"`
+	Coin    string `json:"coin"`
+	Network string `json:"network"`
+}
+
+// ScanFinishedPayload represents completed address scan
+type ScanFinishedPayload struct {
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// UnknownPayload keeps data of notification types not known to this client
+type UnknownPayload struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+// NotificationType implements NotificationPayload
+func (p *NewCopayerPayload) NotificationType() string { return NotificationNewCopayer }
+
+// NotificationType implements NotificationPayload
+func (p *WalletCompletePayload) NotificationType() string { return NotificationWalletComplete }
+
+// NotificationType implements NotificationPayload
+func (p *NewAddressPayload) NotificationType() string { return NotificationNewAddress }
+
+// NotificationType implements NotificationPayload
+func (p *NewBlockPayload) NotificationType() string { return NotificationNewBlock }
+
+// NotificationType implements NotificationPayload
+func (p *NewIncomingTxPayload) NotificationType() string { return NotificationNewIncomingTx }
+
+// NotificationType implements NotificationPayload
+func (p *NewOutgoingTxPayload) NotificationType() string { return NotificationNewOutgoingTx }
+
+// NotificationType implements NotificationPayload
+func (p *NewOutgoingTxByThirdPartyPayload) NotificationType() string {
+	return NotificationNewOutgoingTxByThirdParty
+}
+
+// NotificationType implements NotificationPayload
+func (p *NewTxProposalPayload) NotificationType() string { return NotificationNewTxProposal }
+
+// NotificationType implements NotificationPayload
+func (p *TxProposalAcceptedByPayload) NotificationType() string {
+	return NotificationTxProposalAcceptedBy
+}
+
+// NotificationType implements NotificationPayload
+func (p *TxProposalRejectedByPayload) NotificationType() string {
+	return NotificationTxProposalRejectedBy
+}
+
+// NotificationType implements NotificationPayload
+func (p *TxProposalFinallyAcceptedPayload) NotificationType() string {
+	return NotificationTxProposalFinallyAccepted
+}
+
+// NotificationType implements NotificationPayload
+func (p *TxProposalFinallyRejectedPayload) NotificationType() string {
+	return NotificationTxProposalFinallyRejected
+}
+
+// NotificationType implements NotificationPayload
+func (p *TxProposalRemovedPayload) NotificationType() string { return NotificationTxProposalRemoved }
+
+// NotificationType implements NotificationPayload
+func (p *TxConfirmationPayload) NotificationType() string { return NotificationTxConfirmation }
+
+// NotificationType implements NotificationPayload
+func (p *ScanFinishedPayload) NotificationType() string { return NotificationScanFinished }
+
+// NotificationType implements NotificationPayload
+func (p *UnknownPayload) NotificationType() string { return p.Type }
+
+// newPayload returns empty payload structure for notification type
+func newPayload(notificationType string) NotificationPayload {
+	switch notificationType {
+	case NotificationNewCopayer:
+		return &NewCopayerPayload{}
+	case NotificationWalletComplete:
+		return &WalletCompletePayload{}
+	case NotificationNewAddress:
+		return &NewAddressPayload{}
+	case NotificationNewBlock:
+		return &NewBlockPayload{}
+	case NotificationNewIncomingTx:
+		return &NewIncomingTxPayload{}
+	case NotificationNewOutgoingTx:
+		return &NewOutgoingTxPayload{}
+	case NotificationNewOutgoingTxByThirdParty:
+		return &NewOutgoingTxByThirdPartyPayload{}
+	case NotificationNewTxProposal:
+		return &NewTxProposalPayload{}
+	case NotificationTxProposalAcceptedBy:
+		return &TxProposalAcceptedByPayload{}
+	case NotificationTxProposalRejectedBy:
+		return &TxProposalRejectedByPayload{}
+	case NotificationTxProposalFinallyAccepted:
+		return &TxProposalFinallyAcceptedPayload{}
+	case NotificationTxProposalFinallyRejected:
+		return &TxProposalFinallyRejectedPayload{}
+	case NotificationTxProposalRemoved:
+		return &TxProposalRemovedPayload{}
+	case NotificationTxConfirmation:
+		return &TxConfirmationPayload{}
+	case NotificationScanFinished:
+		return &ScanFinishedPayload{}
+	}
+
+	return nil
+}
+
+// DecodeNotificationPayload decodes JSON notification data according to its type,
+// unknown types are returned as UnknownPayload with data kept as is
+func DecodeNotificationPayload(notificationType string, data []byte) (NotificationPayload, error) {
+	payload := newPayload(notificationType)
+	if payload == nil {
+		raw := make(json.RawMessage, len(data))
+		copy(raw, data)
+		return &UnknownPayload{Type: notificationType, Raw: raw}, nil
+	}
+
+	if len(data) == 0 || string(data) == "null" {
+		return payload, nil
+	}
+
+	if err := json.Unmarshal(data, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// UnmarshalJSON decodes notification keeping its data as received, since decoding
+// into map loses key order and precision of large numbers
+func (n *Notification) UnmarshalJSON(data []byte) error {
+	type notification Notification
+	aux := &struct {
+		*notification
+		Data json.RawMessage `json:"data"`
+	}{notification: (*notification)(n)}
+
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	n.Data = nil
+	n.raw = nil
+	if len(aux.Data) == 0 || string(aux.Data) == "null" {
+		return nil
+	}
+
+	n.raw = aux.Data
+
+	return json.Unmarshal(aux.Data, &n.Data)
+}
+
+// Payload decodes notification data into typed structure based on notification type,
+// using data as received when notification was decoded from JSON
+func (n *Notification) Payload() (NotificationPayload, error) {
+	if n.raw != nil {
+		return DecodeNotificationPayload(n.Type, n.raw)
+	}
+
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeNotificationPayload(n.Type, data)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationPayload(t *testing.T) {
+	notification := &Notification{}
+	data := `{"id":"1538558571001","type":"NewIncomingTx","data":{"txid":"b9a9","address":"mueUUsavi1NaYQKqvtW9ANQtZscrcBt19j","amount":2100000000000000}}`
+	assert.Nil(t, json.Unmarshal([]byte(data), notification), "should decode notification")
+
+	payload, err := notification.Payload()
+	assert.Nil(t, err, "should decode payload")
+	assert.Equal(t, &NewIncomingTxPayload{
+		TxID:    "b9a9",
+		Address: "mueUUsavi1NaYQKqvtW9ANQtZscrcBt19j",
+		Amount:  2100000000000000,
+	}, payload, "should decode typed payload")
+	assert.Equal(t, NotificationNewIncomingTx, payload.NotificationType(), "types should match")
+}
+
+func TestNotificationPayloadTypes(t *testing.T) {
+	payload, err := DecodeNotificationPayload(NotificationTxProposalAcceptedBy, []byte(`{"txProposalId":"txp","copayerId":"cop"}`))
+	assert.Nil(t, err, "should decode payload")
+	assert.Equal(t, &TxProposalAcceptedByPayload{TxProposalID: "txp", CopayerID: "cop"}, payload)
+
+	payload, err = DecodeNotificationPayload(NotificationWalletComplete, []byte(`null`))
+	assert.Nil(t, err, "should accept empty data")
+	assert.Equal(t, &WalletCompletePayload{}, payload)
+
+	_, err = DecodeNotificationPayload(NotificationNewTxProposal, []byte(`{"amount":"many"}`))
+	assert.NotNil(t, err, "should fail on malformed data")
+}
+
+func TestNotificationPayloadUnknown(t *testing.T) {
+	raw := []byte(`{"custom":[1,2,3]}`)
+	payload, err := DecodeNotificationPayload("SomethingNew", raw)
+	assert.Nil(t, err, "should not fail on unknown type")
+
+	unknown, ok := payload.(*UnknownPayload)
+	assert.True(t, ok, "should fall back to unknown payload")
+	assert.Equal(t, "SomethingNew", unknown.NotificationType(), "should keep type")
+	assert.JSONEq(t, string(raw), string(unknown.Raw), "should keep raw data")
+}
+
+func TestNotificationPayloadRaw(t *testing.T) {
+	notification := &Notification{}
+	data := `{"id":"1538558571001","type":"SomethingNew","data":{"zeta":1,"alpha":9007199254740993}}`
+	assert.Nil(t, json.Unmarshal([]byte(data), notification), "should decode notification")
+	assert.Equal(t, float64(1), notification.Data["zeta"], "should decode data map")
+
+	payload, err := notification.Payload()
+	assert.Nil(t, err, "should decode payload")
+	assert.Equal(t, `{"zeta":1,"alpha":9007199254740993}`, string(payload.(*UnknownPayload).Raw), "should keep data as received")
+
+	notification = &Notification{Type: "SomethingNew", Data: map[string]interface{}{"custom": "value"}}
+	payload, err = notification.Payload()
+	assert.Nil(t, err, "should encode data of notification built in code")
+	assert.JSONEq(t, `{"custom":"value"}`, string(payload.(*UnknownPayload).Raw))
+}
@@ -1,12 +1,105 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// PreferenceUnits lists units accepted by BWS preferences
+var PreferenceUnits = []string{"btc", "bit", "bch"}
+
+// PushPlatforms lists push notification platforms accepted by BWS
+var PushPlatforms = []string{"ios", "android"}
+
+var (
+	languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+	emailPattern    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
 // Preferences represent user preferences
 type Preferences struct {
-	Version   string `json:"version"`
-	CreatedOn uint   `json:"createdOn"`
-	WalletID  string `json:"walletId"`
-	CopayerID string `json:"copayerId"`
-	Email     string `json:"email"`
-	Language  string `json:"language"`
-	Unit      string `json:"unit"`
+	Version        string   `json:"version"`
+	CreatedOn      uint     `json:"createdOn"`
+	WalletID       string   `json:"walletId"`
+	CopayerID      string   `json:"copayerId"`
+	Email          string   `json:"email"`
+	Language       string   `json:"language"`
+	Unit           string   `json:"unit"`
+	TokenAddresses []string `json:"tokenAddresses"`
+}
+
+// PushSubscription represents device subscribed to push notifications
+type PushSubscription struct {
+	Platform string `json:"type"`
+	Token    string `json:"token"`
+}
+
+// PreferencesUpdate represents partial preferences update,
+// nil fields are not sent and keep their current values
+type PreferencesUpdate struct {
+	Email          *string             `json:"email,omitempty"`
+	Language       *string             `json:"language,omitempty"`
+	Unit           *string             `json:"unit,omitempty"`
+	TokenAddresses []string            `json:"tokenAddresses,omitempty"`
+	Push           []*PushSubscription `json:"-"`
+}
+
+// Validate checks update values before sending, empty email clears it
+func (p *PreferencesUpdate) Validate() error {
+	if p.Email != nil && *p.Email != "" && !emailPattern.MatchString(*p.Email) {
+		return fmt.Errorf("Invalid email: %s", *p.Email)
+	}
+
+	if p.Language != nil && !languagePattern.MatchString(*p.Language) {
+		return fmt.Errorf("Invalid language: %s", *p.Language)
+	}
+
+	if p.Unit != nil && !contains(PreferenceUnits, *p.Unit) {
+		return fmt.Errorf("Invalid unit: %s", *p.Unit)
+	}
+
+	for _, push := range p.Push {
+		if push == nil || push.Token == "" {
+			return errors.New("Push token not specified")
+		}
+
+		if !contains(PushPlatforms, push.Platform) {
+			return fmt.Errorf("Invalid push platform: %s", push.Platform)
+		}
+	}
+
+	return nil
+}
+
+// Payload returns only fields set in update
+func (p *PreferencesUpdate) Payload() map[string]interface{} {
+	payload := map[string]interface{}{}
+	if p.Email != nil {
+		payload["email"] = *p.Email
+	}
+
+	if p.Language != nil {
+		payload["language"] = *p.Language
+	}
+
+	if p.Unit != nil {
+		payload["unit"] = *p.Unit
+	}
+
+	if p.TokenAddresses != nil {
+		payload["tokenAddresses"] = p.TokenAddresses
+	}
+
+	return payload
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
 }
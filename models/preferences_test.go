@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferencesUpdateValidate(t *testing.T) {
+	valid := &PreferencesUpdate{
+		Email:    pointer.ToString("copayer@example.com"),
+		Language: pointer.ToString("en"),
+		Unit:     pointer.ToString("btc"),
+		Push:     []*PushSubscription{{Platform: "android", Token: "device"}},
+	}
+	assert.Nil(t, valid.Validate(), "should accept valid update")
+	assert.Nil(t, (&PreferencesUpdate{Email: pointer.ToString("")}).Validate(), "should allow clearing email")
+
+	invalid := []*PreferencesUpdate{
+		{Email: pointer.ToString("copayer")},
+		{Language: pointer.ToString("english")},
+		{Unit: pointer.ToString("sat")},
+		{Push: []*PushSubscription{{Platform: "windows", Token: "device"}}},
+		{Push: []*PushSubscription{{Platform: "ios"}}},
+	}
+
+	for _, update := range invalid {
+		assert.Error(t, update.Validate(), "should reject invalid update")
+	}
+}
+
+func TestPreferencesUpdatePayload(t *testing.T) {
+	assert.Empty(t, (&PreferencesUpdate{}).Payload(), "should not send unset fields")
+
+	update := &PreferencesUpdate{
+		Email:          pointer.ToString(""),
+		TokenAddresses: []string{},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"email":          "",
+		"tokenAddresses": []string{},
+	}, update.Payload(), "should send explicitly cleared fields")
+}
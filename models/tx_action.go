@@ -1,5 +1,17 @@
 package models
 
+import (
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// Proposal action types
+const (
+	ActionAccept = "accept"
+	ActionReject = "reject"
+)
+
 // TxAction represents transcation proposal actions
 type TxAction struct {
 	Version     string   `jsons:"version"`
@@ -11,3 +23,18 @@ type TxAction struct {
 	CopayerName string   `json:"copayerName"`
 	Comment     string   `json:"comment"`
 }
+
+// PublicKey derives public key of acting copayer at input path from its account XPub
+func (action *TxAction) PublicKey(path string) (*btcec.PublicKey, error) {
+	xpub, err := hdkeychain.NewKeyFromString(action.XPub)
+	if err != nil {
+		return nil, err
+	}
+
+	child, err := utils.DerivePath(xpub, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return child.ECPubKey()
+}
@@ -1,5 +1,7 @@
 package models
 
+import "fmt"
+
 // TxInput represents transaction input
 type TxInput struct {
 	TxID          string   `json:"txid"`
@@ -11,4 +13,10 @@ type TxInput struct {
 	Locked        bool     `json:"locked"`
 	Path          string   `json:"path"`
 	PublicKeys    []string `json:"publicKeys"`
+	Sequence      *uint32  `json:"sequence,omitempty"` // Custom sequence number, derived from proposal if not set
+}
+
+// Outpoint returns input identifier in txid:vout format
+func (input *TxInput) Outpoint() string {
+	return fmt.Sprintf("%s:%d", input.TxID, input.Vout)
 }
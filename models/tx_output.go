@@ -1,11 +1,34 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+// Data carrier limits of standard transactions, in bytes of data pushed at once
+const (
+	MaxDataSizeBTC = 80
+	MaxDataSizeBCH = 220
+)
+
+// Relay limits of whole OP_RETURN script, in bytes
+const (
+	MaxDataScriptSizeBTC = 83
+	MaxDataScriptSizeBCH = 223
+)
+
 // TxOutput represents transaction output
 type TxOutput struct {
 	Amount    int64   `json:"amount"`
 	Address   string  `json:"address,omitempty"` // For received transactions
 	ToAddress string  `json:"toAddress"`
 	Message   *string `json:"message"`
+	Script    string  `json:"script,omitempty"` // Hex-encoded OP_RETURN script of data outputs
 }
 
 // NewTxOutput creates new tx output without a message
@@ -22,3 +45,109 @@ func NewTxOutputSingle(amount int64, toAddress string) []*TxOutput {
 	result = append(result, NewTxOutput(amount, toAddress))
 	return result
 }
+
+// NewDataOutput creates zero-value OP_RETURN output carrying data
+func NewDataOutput(data []byte) (*TxOutput, error) {
+	if len(data) == 0 {
+		return nil, errors.New("Output data not specified")
+	}
+
+	script, err := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).AddData(data).Script()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxOutput{Script: utils.ToHex(script)}, nil
+}
+
+// MaxDataSize returns largest data relayed in single push of OP_RETURN output of coin
+func MaxDataSize(coin string) int {
+	if coin == config.CoinBCH {
+		return MaxDataSizeBCH
+	}
+
+	return MaxDataSizeBTC
+}
+
+// MaxDataScriptSize returns largest OP_RETURN script relayed by nodes of coin
+func MaxDataScriptSize(coin string) int {
+	if coin == config.CoinBCH {
+		return MaxDataScriptSizeBCH
+	}
+
+	return MaxDataScriptSizeBTC
+}
+
+// ParseDataScript returns data pushed by OP_RETURN script
+func ParseDataScript(script []byte) ([]byte, error) {
+	if len(script) == 0 || script[0] != txscript.OP_RETURN {
+		return nil, errors.New("Not an OP_RETURN script")
+	}
+
+	if !txscript.IsPushOnlyScript(script[1:]) {
+		return nil, errors.New("OP_RETURN script must only push data")
+	}
+
+	pushes, err := txscript.PushedData(script[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte{}
+	for _, push := range pushes {
+		data = append(data, push...)
+	}
+
+	return data, nil
+}
+
+// IsData reports whether output carries data instead of paying address
+func (o *TxOutput) IsData() bool {
+	return o.Script != ""
+}
+
+// Data returns data carried by OP_RETURN output
+func (o *TxOutput) Data() ([]byte, error) {
+	script, err := utils.ToBytes(o.Script)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDataScript(script)
+}
+
+// ValidateData checks that data output has no value and address and its script fits size limit of coin
+func (o *TxOutput) ValidateData(coin string) error {
+	if o.Amount != 0 || o.ToAddress != "" {
+		return errors.New("Data output must have no amount and address")
+	}
+
+	if _, err := o.Data(); err != nil {
+		return err
+	}
+
+	// Relay policy limits whole script, several pushes carry less data than a single one
+	if max := MaxDataScriptSize(coin); len(o.Script)/2 > max {
+		return fmt.Errorf("Output script of %d bytes exceeds limit of %d", len(o.Script)/2, max)
+	}
+
+	return nil
+}
+
+// PkScript returns output script paying address of coin or carrying data
+func (o *TxOutput) PkScript(coin string, net *chaincfg.Params) ([]byte, error) {
+	if o.IsData() {
+		if _, err := o.Data(); err != nil {
+			return nil, err
+		}
+
+		return utils.ToBytes(o.Script)
+	}
+
+	address, err := DecodeAddress(o.ToAddress, coin, net)
+	if err != nil {
+		return nil, err
+	}
+
+	return txscript.PayToAddrScript(address)
+}
@@ -1,8 +1,13 @@
 package models
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/pavel-main/bws-go/config"
+	"github.com/pavel-main/bws-go/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +27,77 @@ func TestNewTxOutputSingle(t *testing.T) {
 	assert.Equal(t, expected, txOuts[0].Amount, "amounts should match")
 	assert.Equal(t, "mueUUsavi1NaYQKqvtW9ANQtZscrcBt19j", txOuts[0].ToAddress, "addresses should match")
 }
+
+func TestNewDataOutput(t *testing.T) {
+	output, err := NewDataOutput([]byte("hello"))
+	assert.NoError(t, err, "should create data output")
+	assert.True(t, output.IsData(), "should be data output")
+	assert.Equal(t, "6a0568656c6c6f", output.Script, "should push data after OP_RETURN")
+
+	data, err := output.Data()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data, "data should match")
+	assert.NoError(t, output.ValidateData(config.CoinBTC), "should accept small data")
+
+	_, err = NewDataOutput(nil)
+	assert.Error(t, err, "should require data")
+	assert.False(t, NewTxOutput(1000, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY").IsData(), "should not treat payment as data")
+}
+
+func TestValidateData(t *testing.T) {
+	output, _ := NewDataOutput(bytes.Repeat([]byte{1}, MaxDataSizeBTC+1))
+	assert.Error(t, output.ValidateData(config.CoinBTC), "should enforce BTC data limit")
+	assert.NoError(t, output.ValidateData(config.CoinBCH), "should allow larger BCH data")
+
+	output, _ = NewDataOutput(bytes.Repeat([]byte{1}, MaxDataSizeBCH+1))
+	assert.Error(t, output.ValidateData(config.CoinBCH), "should enforce BCH data limit")
+
+	output, _ = NewDataOutput(bytes.Repeat([]byte{1}, MaxDataSizeBTC))
+	assert.NoError(t, output.ValidateData(config.CoinBTC), "should accept largest single push")
+
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN)
+	for i := 0; i < 4; i++ {
+		builder.AddData(bytes.Repeat([]byte{1}, MaxDataSizeBTC/4))
+	}
+
+	script, _ := builder.Script()
+	output = &TxOutput{Script: utils.ToHex(script)}
+	assert.Error(t, output.ValidateData(config.CoinBTC), "should limit whole script of several pushes")
+
+	output, _ = NewDataOutput([]byte("hello"))
+	output.Amount = 1000
+	assert.Error(t, output.ValidateData(config.CoinBTC), "should not burn coins")
+
+	output.Amount = 0
+	output.ToAddress = "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"
+	assert.Error(t, output.ValidateData(config.CoinBTC), "should not send data to address")
+
+	output = &TxOutput{Script: "76a9143874e8eb8a2e018c46721fcdd8e9049f619af35488ac"}
+	assert.Error(t, output.ValidateData(config.CoinBTC), "should require OP_RETURN script")
+}
+
+func TestPkScriptCashAddr(t *testing.T) {
+	net := &chaincfg.MainNetParams
+	pairs := map[string]string{
+		"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a":             "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		"bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq": "3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC",
+	}
+
+	for cashAddr, legacy := range pairs {
+		expected, err := NewTxOutput(1000, legacy).PkScript(config.CoinBCH, net)
+		assert.NoError(t, err, "should build script of legacy address")
+
+		script, err := NewTxOutput(1000, cashAddr).PkScript(config.CoinBCH, net)
+		assert.NoError(t, err, "should build script of CashAddr address")
+		assert.Equal(t, expected, script, "scripts should match")
+
+		_, err = NewTxOutput(1000, cashAddr).PkScript(config.CoinBTC, net)
+		assert.Error(t, err, "should reject CashAddr address of BTC output")
+	}
+
+	_, err := NewTxOutput(1000, "bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a").PkScript(config.CoinBCH, net)
+	assert.Error(t, err, "should reject address of other network")
+
+	_, err = NewTxOutput(1000, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq").PkScript(config.CoinBCH, net)
+	assert.Error(t, err, "should reject segwit address of BCH output")
+}
@@ -2,8 +2,11 @@ package models
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"sort"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -14,67 +17,137 @@ import (
 	"github.com/pavel-main/bws-go/utils"
 )
 
+// RBFSequence is input sequence number signalling opt-in replace-by-fee (BIP125)
+const RBFSequence uint32 = wire.MaxTxInSequenceNum - 2
+
+// LockTimeSequence is default input sequence number enabling lock time without signalling RBF
+const LockTimeSequence uint32 = wire.MaxTxInSequenceNum - 1
+
+// LockTimeThreshold separates block heights from unix timestamps in lock time
+const LockTimeThreshold uint32 = txscript.LockTimeThreshold
+
 // TxProposal represents transaction proposal
 type TxProposal struct {
-	ID                      string      `json:"id"`
-	TxID                    string      `json:"txId"`
-	WalletID                string      `json:"walletId"`
-	CreatorID               string      `json:"creatorId"`
-	Version                 int32       `json:"version"`
-	CreatedOn               uint        `json:"createdOn"`
-	BroadcastedOn           uint        `json:"broadcastedOn"`
-	Coin                    string      `json:"coin"`
-	Network                 string      `json:"network"`
-	Message                 *string     `json:"message"`
-	PayProURL               *string     `json:"payProUrl"`
-	WalletM                 int         `json:"walletM"`
-	WalletN                 int         `json:"walletN"`
-	RequiredSignatures      uint        `json:"requiredSignatures"`
-	RequiredRejections      uint        `json:"requiredRejections"`
-	Status                  string      `json:"status"`
-	FeeLevel                string      `json:"feeLevel"`
-	FeePerKB                uint        `json:"feePerKb"`
-	ExcludeUnconfirmedUtxos bool        `json:"excludeUnconfrimedUtxos"`
-	AddressType             string      `json:"addressType"`
-	Amount                  int64       `json:"amount"`
-	Fee                     int64       `json:"fee"`
-	CreatorName             string      `json:"creatorName"`
-	HasUnconfirmedInputs    bool        `json:"hasUnconfirmedInputs"`
-	InputPaths              []string    `json:"inputPaths"`
-	OutputOrder             []int       `json:"outputOrder"`
-	ChangeAddress           *Address    `json:"changeAddress"`
-	Inputs                  []*TxInput  `json:"inputs"`
-	Outputs                 []*TxOutput `json:"outputs"`
-	Actions                 []*TxAction `json:"actions"`
-	redeemScript            []byte
+	ID                        string      `json:"id"`
+	TxID                      string      `json:"txId"`
+	WalletID                  string      `json:"walletId"`
+	CreatorID                 string      `json:"creatorId"`
+	Version                   int32       `json:"version"`
+	CreatedOn                 uint        `json:"createdOn"`
+	BroadcastedOn             uint        `json:"broadcastedOn"`
+	Coin                      string      `json:"coin"`
+	Network                   string      `json:"network"`
+	Message                   *string     `json:"message"`
+	PayProURL                 *string     `json:"payProUrl"`
+	WalletM                   int         `json:"walletM"`
+	WalletN                   int         `json:"walletN"`
+	RequiredSignatures        uint        `json:"requiredSignatures"`
+	RequiredRejections        uint        `json:"requiredRejections"`
+	Status                    string      `json:"status"`
+	FeeLevel                  string      `json:"feeLevel"`
+	FeePerKB                  uint        `json:"feePerKb"`
+	ExcludeUnconfirmedUtxos   bool        `json:"excludeUnconfrimedUtxos"`
+	NoShuffleOutputs          bool        `json:"noShuffleOutputs"`
+	EnableRBF                 bool        `json:"enableRBF"`
+	LockTime                  uint32      `json:"lockUntilBlockHeight,omitempty"` // Block height on server, local recovery proposals may also hold unix timestamp
+	CustomData                interface{} `json:"customData,omitempty"`
+	AddressType               string      `json:"addressType"`
+	Amount                    int64       `json:"amount"`
+	Fee                       int64       `json:"fee"`
+	CreatorName               string      `json:"creatorName"`
+	HasUnconfirmedInputs      bool        `json:"hasUnconfirmedInputs"`
+	InputPaths                []string    `json:"inputPaths"`
+	OutputOrder               []int       `json:"outputOrder"`
+	ChangeAddress             *Address    `json:"changeAddress"`
+	Inputs                    []*TxInput  `json:"inputs"`
+	Outputs                   []*TxOutput `json:"outputs"`
+	Actions                   []*TxAction `json:"actions"`
+	CreatorSignature          string      `json:"proposalSignature,omitempty"`
+	CreatorSignaturePubKey    string      `json:"proposalSignaturePubKey,omitempty"`
+	CreatorSignaturePubKeySig string      `json:"proposalSignaturePubKeySig,omitempty"` // Authorization of signing key by creator account key
 }
 
 // Validate performs basic validation before serialization
 func (txp *TxProposal) Validate() error {
-	// Validate outputs (second one is implied by ChangeAddress)
-	if len(txp.Outputs) != 1 {
-		return errors.New("Only transactions with two outputs are supported")
+	if len(txp.Outputs) == 0 {
+		return errors.New("No outputs specified")
 	}
 
-	// Validate output order
-	if len(txp.OutputOrder) != 2 {
+	// Output order covers outputs and change, proposals spending everything (send max) may omit change
+	count := len(txp.Outputs) + 1
+	if len(txp.OutputOrder) != count && !(len(txp.OutputOrder) == count-1 && txp.ChangeAddress == nil) {
 		return errors.New("Invalid output order")
 	}
 
+	seen := map[int]bool{}
+	for _, idx := range txp.OutputOrder {
+		if idx < 0 || idx >= count || seen[idx] {
+			return errors.New("Invalid output order")
+		}
+
+		seen[idx] = true
+	}
+
 	// Validate change address
-	if txp.ChangeAddress == nil {
+	if len(txp.OutputOrder) == count && txp.ChangeAddress == nil {
 		return errors.New("Change address not specified")
 	}
 
+	return txp.validateSequences()
+}
+
+// validateSequences checks that input sequences enable lock time and RBF as requested
+func (txp *TxProposal) validateSequences() error {
+	final := true
+	for idx, input := range txp.Inputs {
+		sequence := txp.InputSequence(input)
+		if sequence != wire.MaxTxInSequenceNum {
+			final = false
+		}
+
+		if txp.EnableRBF && sequence > RBFSequence {
+			return fmt.Errorf("Input %d sequence %d does not signal replace-by-fee", idx, sequence)
+		}
+	}
+
+	// Lock time is ignored by consensus when every input is final
+	if txp.LockTime != 0 && final && len(txp.Inputs) != 0 {
+		return errors.New("Lock time requires at least one non-final input sequence")
+	}
+
 	return nil
 }
 
-// BuildRedeemScript builds multisig redeem script
+// IsTimeLocked reports whether lock time is unix timestamp rather than block height
+func (txp *TxProposal) IsTimeLocked() bool {
+	return txp.LockTime >= LockTimeThreshold
+}
+
+// IsFinal reports whether transaction may be included in block at height, whose
+// median time past is given, as lock time is checked by consensus
+func (txp *TxProposal) IsFinal(height int32, medianTime time.Time) bool {
+	if txp.LockTime == 0 {
+		return true
+	}
+
+	if txp.IsTimeLocked() {
+		return int64(txp.LockTime) < medianTime.Unix()
+	}
+
+	return int64(txp.LockTime) < int64(height)
+}
+
+// BuildRedeemScript builds multisig redeem script of input address from its sorted public keys
 func (txp *TxProposal) BuildRedeemScript(input *TxInput, net *chaincfg.Params) ([]byte, error) {
-	sort.Strings(input.PublicKeys)
+	if len(input.PublicKeys) == 0 {
+		return nil, errors.New("Input public keys not specified")
+	}
+
+	keys := append([]string{}, input.PublicKeys...)
+	sort.Strings(keys)
 
 	multiSigBuilder := txscript.NewScriptBuilder().AddInt64(int64(txp.WalletM))
-	for _, key := range input.PublicKeys {
+	for _, key := range keys {
 		bytes, err := utils.ToBytes(key)
 		if err != nil {
 			return nil, err
@@ -83,7 +156,7 @@ func (txp *TxProposal) BuildRedeemScript(input *TxInput, net *chaincfg.Params) (
 		multiSigBuilder.AddData(bytes)
 	}
 
-	multiSigBuilder.AddInt64(int64(len(input.PublicKeys)))
+	multiSigBuilder.AddInt64(int64(len(keys)))
 	multiSigBuilder.AddOp(txscript.OP_CHECKMULTISIG)
 
 	multiSigScript, err := multiSigBuilder.Script()
@@ -94,6 +167,52 @@ func (txp *TxProposal) BuildRedeemScript(input *TxInput, net *chaincfg.Params) (
 	return multiSigScript, nil
 }
 
+// BuildInputScript builds script of input address from its public keys, the only key of single
+// signature address or multisig keys, so server-supplied ScriptPubKey can be checked against it
+func (txp *TxProposal) BuildInputScript(input *TxInput, net *chaincfg.Params) ([]byte, error) {
+	address, err := txp.inputAddress(input, net)
+	if err != nil {
+		return nil, err
+	}
+
+	return txscript.PayToAddrScript(address)
+}
+
+func (txp *TxProposal) inputAddress(input *TxInput, net *chaincfg.Params) (btcutil.Address, error) {
+	addressType := txp.addressType()
+	switch addressType {
+	case AddressTypeP2SH, AddressTypeP2WSH:
+		redeemScript, err := txp.BuildRedeemScript(input, net)
+		if err != nil {
+			return nil, err
+		}
+
+		if addressType == AddressTypeP2WSH {
+			hash := sha256.Sum256(redeemScript)
+			return btcutil.NewAddressWitnessScriptHash(hash[:], net)
+		}
+
+		return btcutil.NewAddressScriptHash(redeemScript, net)
+	case AddressTypeP2PKH, AddressTypeP2WPKH:
+		if len(input.PublicKeys) != 1 {
+			return nil, errors.New("Single signature input must have one public key")
+		}
+
+		key, err := utils.ToBytes(input.PublicKeys[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if addressType == AddressTypeP2WPKH {
+			return btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(key), net)
+		}
+
+		return btcutil.NewAddressPubKeyHash(btcutil.Hash160(key), net)
+	}
+
+	return nil, fmt.Errorf("Unknown address type: %s", addressType)
+}
+
 // ToTransaction converts tx proposal to btcd transaction type
 func (txp *TxProposal) ToTransaction(net *chaincfg.Params) (*wire.MsgTx, error) {
 	// Basic validation
@@ -103,18 +222,17 @@ func (txp *TxProposal) ToTransaction(net *chaincfg.Params) (*wire.MsgTx, error)
 
 	// Build tx
 	tx := wire.NewMsgTx(wire.TxVersion)
-	to := txp.Outputs[0].ToAddress // Only single destination is now supported
+	tx.LockTime = txp.LockTime
 
-	// Decode destination address
-	toDest, err := btcutil.DecodeAddress(to, net)
-	if err != nil {
-		return nil, err
-	}
+	// Build recipient and data outputs
+	outputs := []*wire.TxOut{}
+	for _, output := range txp.Outputs {
+		script, err := output.PkScript(txp.Coin, net)
+		if err != nil {
+			return nil, err
+		}
 
-	// Build destination output
-	toScript, err := txscript.PayToAddrScript(toDest)
-	if err != nil {
-		return nil, err
+		outputs = append(outputs, wire.NewTxOut(output.Amount, script))
 	}
 
 	// Add inputs
@@ -130,30 +248,37 @@ func (txp *TxProposal) ToTransaction(net *chaincfg.Params) (*wire.MsgTx, error)
 		return nil, inputErr
 	}
 
-	// Decode change address
-	toChange, err := btcutil.DecodeAddress(txp.ChangeAddress.Address, net)
-	if err != nil {
-		return nil, err
+	// Inputs must pay declared fee exactly
+	if change < 0 {
+		return nil, errors.New("Inputs do not cover amount and fee")
 	}
 
-	// Build change output
-	changeScript, err := txscript.PayToAddrScript(toChange)
-	if err != nil {
-		return nil, err
-	}
-
-	// Default output order
-	if txp.OutputOrder[0] == 0 {
-		tx.AddTxOut(wire.NewTxOut(txp.Amount, toScript))
+	// Proposal without change output
+	if txp.ChangeAddress == nil {
+		if change != 0 {
+			return nil, errors.New("Inputs do not match amount and fee")
+		}
+	} else if change > 0 {
+		// Decode change address
+		toChange, err := DecodeAddress(txp.ChangeAddress.Address, txp.Coin, net)
+		if err != nil {
+			return nil, err
+		}
 
-		if change > 0 {
-			tx.AddTxOut(wire.NewTxOut(change, changeScript))
+		// Build change output
+		changeScript, err := txscript.PayToAddrScript(toChange)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		if change > 0 {
-			tx.AddTxOut(wire.NewTxOut(change, changeScript))
+
+		outputs = append(outputs, wire.NewTxOut(change, changeScript))
+	}
+
+	// Outputs are shuffled by server, missing change is skipped
+	for _, idx := range txp.OutputOrder {
+		if idx < len(outputs) {
+			tx.AddTxOut(outputs[idx])
 		}
-		tx.AddTxOut(wire.NewTxOut(txp.Amount, toScript))
 	}
 
 	return tx, nil
@@ -169,6 +294,7 @@ func (txp *TxProposal) AddInputs(tx *wire.MsgTx, net *chaincfg.Params) (int64, e
 
 		outPoint := wire.NewOutPoint(hash, input.Vout)
 		txInput := wire.NewTxIn(outPoint, nil, nil)
+		txInput.Sequence = txp.InputSequence(input)
 		tx.AddTxIn(txInput)
 		total += input.Satoshis
 	}
@@ -181,19 +307,16 @@ func (txp *TxProposal) AddInputs(tx *wire.MsgTx, net *chaincfg.Params) (int64, e
 func (txp *TxProposal) AddMultisigInputs(tx *wire.MsgTx, net *chaincfg.Params) (int64, error) {
 	var total int64
 	for _, input := range txp.Inputs {
-		if len(txp.redeemScript) == 0 {
-			redeemScript, err := txp.BuildRedeemScript(input, net)
-			if err != nil {
-				return 0, err
-			}
-
-			txp.redeemScript = redeemScript
+		// Inputs may spend different addresses, each having its own keys
+		redeemScript, err := txp.BuildRedeemScript(input, net)
+		if err != nil {
+			return 0, err
 		}
 
 		// Build multi-sig input script
 		builder := txscript.NewScriptBuilder().
 			AddInt64(txscript.OP_0).
-			AddData(txp.redeemScript)
+			AddData(redeemScript)
 
 		script, err := builder.Script()
 		if err != nil {
@@ -207,6 +330,7 @@ func (txp *TxProposal) AddMultisigInputs(tx *wire.MsgTx, net *chaincfg.Params) (
 
 		outPoint := wire.NewOutPoint(hash, input.Vout)
 		txInput := wire.NewTxIn(outPoint, script, nil)
+		txInput.Sequence = txp.InputSequence(input)
 		tx.AddTxIn(txInput)
 		total += input.Satoshis
 	}
@@ -216,6 +340,21 @@ func (txp *TxProposal) AddMultisigInputs(tx *wire.MsgTx, net *chaincfg.Params) (
 	return change, nil
 }
 
+// InputSequence returns input sequence number, custom one if set, otherwise
+// signalling RBF if enabled or enabling lock time if set
+func (txp *TxProposal) InputSequence(input *TxInput) uint32 {
+	switch {
+	case input.Sequence != nil:
+		return *input.Sequence
+	case txp.EnableRBF:
+		return RBFSequence
+	case txp.LockTime != 0:
+		return LockTimeSequence
+	}
+
+	return wire.MaxTxInSequenceNum
+}
+
 // Serialize returns raw transaction from proposal data
 func (txp *TxProposal) Serialize(net *chaincfg.Params) ([]byte, error) {
 	// Convert
@@ -260,17 +399,7 @@ func (txp *TxProposal) InputSignature(privKey *btcec.PrivateKey, net *chaincfg.P
 		return nil, err
 	}
 
-	pkScript := txp.redeemScript
-	if txp.WalletM == 1 {
-		outputScript, err := utils.ToBytes(txp.Inputs[idx].ScriptPubKey)
-		if err != nil {
-			return nil, err
-		}
-
-		pkScript = outputScript
-	}
-
-	hash, err := txscript.CalcSignatureHash(pkScript, txscript.SigHashAll, tx, idx)
+	hash, err := txp.signatureHash(tx, net, idx)
 	if err != nil {
 		return nil, err
 	}
@@ -282,3 +411,34 @@ func (txp *TxProposal) InputSignature(privKey *btcec.PrivateKey, net *chaincfg.P
 
 	return signature.Serialize(), nil
 }
+
+// signatureHash returns hash of transaction input signed by copayers, multisig inputs
+// commit to redeem script of their own address and segwit inputs to their amount (BIP143)
+func (txp *TxProposal) signatureHash(tx *wire.MsgTx, net *chaincfg.Params, idx int) ([]byte, error) {
+	if idx < 0 || idx >= len(txp.Inputs) {
+		return nil, fmt.Errorf("Input %d not found", idx)
+	}
+
+	input := txp.Inputs[idx]
+	addressType := txp.addressType()
+	var script []byte
+	var err error
+	switch addressType {
+	case AddressTypeP2SH, AddressTypeP2WSH:
+		script, err = txp.BuildRedeemScript(input, net)
+	case AddressTypeP2PKH, AddressTypeP2WPKH:
+		script, err = txp.prevScript(input, net)
+	default:
+		err = fmt.Errorf("Unknown address type: %s", addressType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if addressType == AddressTypeP2WPKH || addressType == AddressTypeP2WSH {
+		return txscript.CalcWitnessSigHash(script, txscript.NewTxSigHashes(tx), txscript.SigHashAll, tx, idx, input.Satoshis)
+	}
+
+	return txscript.CalcSignatureHash(script, txscript.SigHashAll, tx, idx)
+}
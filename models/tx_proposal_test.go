@@ -3,9 +3,12 @@ package models
 import (
 	"encoding/hex"
 	"testing"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/pavel-main/bws-go/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -168,3 +171,165 @@ func TestValidateExceptionPath(t *testing.T) {
 	err = txp.Validate()
 	assert.NoError(t, err, "should succeed finally")
 }
+
+func TestSerializeWithoutChange(t *testing.T) {
+	txp := &TxProposal{
+		Amount:      14110166,
+		Fee:         246,
+		OutputOrder: []int{0},
+		Inputs: []*TxInput{
+			{
+				TxID:     "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4",
+				Satoshis: 14110412,
+				Vout:     1,
+			},
+		},
+		Outputs: NewTxOutputSingle(14110166, "mnv9rH2VfAUX9YZzFkoRysGFtggvz1wRnY"),
+	}
+
+	tx, err := txp.ToTransaction(net)
+	assert.NoError(t, err, "should build transaction spending everything")
+	assert.Len(t, tx.TxOut, 1, "should not add change output")
+	assert.Equal(t, int64(14110166), tx.TxOut[0].Value, "amounts should match")
+
+	txp.Fee = 100
+	_, err = txp.ToTransaction(net)
+	assert.Error(t, err, "should not burn unspent change as fee")
+}
+
+func TestSerializeNegativeChange(t *testing.T) {
+	txp := *mockTxp
+	txp.Fee = 14110412
+	_, err := txp.ToTransaction(net)
+	assert.Error(t, err, "should not drop change paying less fee than declared")
+}
+
+func TestSerializeReplaceable(t *testing.T) {
+	txp := *mockTxp
+	tx, err := txp.ToTransaction(net)
+	assert.NoError(t, err)
+	assert.Equal(t, wire.MaxTxInSequenceNum, tx.TxIn[0].Sequence, "should not signal RBF by default")
+
+	txp.EnableRBF = true
+	tx, err = txp.ToTransaction(net)
+	assert.NoError(t, err)
+	assert.Equal(t, RBFSequence, tx.TxIn[0].Sequence, "should signal RBF")
+}
+
+func TestMultisigInputsFromSeveralAddresses(t *testing.T) {
+	copayers := newMockCopayers(3)
+	first := mockMultisigInput(copayers, 2, "m/0/0", "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", 60000)
+	second := mockMultisigInput(copayers, 2, "m/1/3", "b53d75d4b45b574d8200c2539b0af761dddc94aa2005047643e2a5a71a695d52", 70000)
+	third := mockMultisigInput(copayers, 2, "m/0/7", "cd123d19e7b85f672213064e78cd868c53e84ef8b5ab079ecead251025b0e170", 80000)
+	txp := mockUnsignedTxp(2, 3, first, second, third)
+
+	keys := append([]string{}, second.PublicKeys...)
+	tx, err := txp.ToTransaction(net)
+	assert.NoError(t, err, "should build transaction")
+	assert.Equal(t, keys, second.PublicKeys, "should not reorder input keys")
+
+	for idx, input := range txp.Inputs {
+		redeemScript, _ := txp.BuildRedeemScript(input, net)
+		pushes, _ := txscript.PushedData(tx.TxIn[idx].SignatureScript)
+		assert.Equal(t, redeemScript, pushes[len(pushes)-1], "should use redeem script of input address")
+	}
+
+	accept(t, txp, copayers[0])
+	accept(t, txp, copayers[2])
+
+	wallet := &Wallet{M: 2, N: 3}
+	for _, copayer := range copayers {
+		wallet.Copayers = append(wallet.Copayers, &Copayer{ID: copayer.id, XPubKey: copayer.xpub()})
+	}
+
+	assert.NoError(t, txp.VerifyInputSignatures(wallet, net), "should verify signatures of every input")
+
+	signed, err := txp.Finalize(net)
+	assert.NoError(t, err, "should finalize transaction spending several addresses")
+	assert.Len(t, signed.TxIn, 3)
+
+	privKey, _ := copayers[0].keys(first.Path)
+	_, err = txp.InputSignature(privKey, net, 3)
+	assert.Error(t, err, "should reject unknown input")
+}
+
+func TestSerializeWithData(t *testing.T) {
+	copayers := newMockCopayers(2)
+	input := mockMultisigInput(copayers, 2, "m/0/0", "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", 100000)
+	txp := mockUnsignedTxp(2, 2, input)
+
+	data, _ := NewDataOutput([]byte("hello"))
+	txp.Outputs = append(txp.Outputs, data)
+	assert.Error(t, txp.Validate(), "should require order of data output")
+
+	txp.OutputOrder = []int{2, 1, 0}
+	tx, err := txp.ToTransaction(net)
+	assert.NoError(t, err, "should build transaction with data output")
+	assert.Len(t, tx.TxOut, 3)
+	assert.Equal(t, txp.Amount, tx.TxOut[2].Value, "should follow output order")
+	assert.Equal(t, int64(0), tx.TxOut[1].Value, "should not burn coins")
+	assert.Equal(t, txscript.NullDataTy, txscript.GetScriptClass(tx.TxOut[1].PkScript), "should build OP_RETURN output")
+
+	accept(t, txp, copayers[0])
+	accept(t, txp, copayers[1])
+	_, err = txp.Finalize(net)
+	assert.NoError(t, err, "should finalize signed transaction with data output")
+}
+
+func TestSerializeLockTime(t *testing.T) {
+	copayers := newMockCopayers(2)
+	first := mockMultisigInput(copayers, 2, "m/0/0", "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", 60000)
+	second := mockMultisigInput(copayers, 2, "m/0/1", "b53d75d4b45b574d8200c2539b0af761dddc94aa2005047643e2a5a71a695d52", 70000)
+	txp := mockUnsignedTxp(2, 2, first, second)
+
+	private, _ := copayers[0].keys(first.Path)
+	unlocked, _ := txp.InputSignature(private, net, 0)
+
+	txp.LockTime = 1500000
+	tx, err := txp.ToTransaction(net)
+	assert.NoError(t, err, "should build timelocked transaction")
+	assert.Equal(t, uint32(1500000), tx.LockTime, "lock time should match")
+	assert.Equal(t, LockTimeSequence, tx.TxIn[0].Sequence, "should enable lock time by default")
+
+	locked, _ := txp.InputSignature(private, net, 0)
+	assert.NotEqual(t, unlocked, locked, "should commit to lock time")
+
+	sequence := wire.MaxTxInSequenceNum
+	first.Sequence = &sequence
+	tx, err = txp.ToTransaction(net)
+	assert.NoError(t, err, "should allow final input while other enables lock time")
+	assert.Equal(t, wire.MaxTxInSequenceNum, tx.TxIn[0].Sequence, "should use custom sequence")
+	assert.Equal(t, LockTimeSequence, tx.TxIn[1].Sequence)
+
+	second.Sequence = &sequence
+	assert.Error(t, txp.Validate(), "should reject lock time disabled by final inputs")
+
+	txp.LockTime = 0
+	txp.EnableRBF = true
+	assert.Error(t, txp.Validate(), "should reject sequence not signalling RBF")
+
+	relative := uint32(144)
+	second.Sequence = &relative
+	first.Sequence = nil
+	txp.LockTime = 1500000
+	accept(t, txp, copayers[0])
+	accept(t, txp, copayers[1])
+	tx, err = txp.Finalize(net)
+	assert.NoError(t, err, "should finalize timelocked transaction")
+	assert.Equal(t, []uint32{RBFSequence, relative}, []uint32{tx.TxIn[0].Sequence, tx.TxIn[1].Sequence})
+}
+
+func TestIsFinal(t *testing.T) {
+	txp := &TxProposal{}
+	assert.True(t, txp.IsFinal(0, time.Unix(0, 0)), "should be final without lock time")
+
+	txp.LockTime = 1500000
+	assert.False(t, txp.IsTimeLocked())
+	assert.False(t, txp.IsFinal(1500000, time.Now()), "should not be final at lock height")
+	assert.True(t, txp.IsFinal(1500001, time.Now()), "should be final after lock height")
+
+	txp.LockTime = 1600000000
+	assert.True(t, txp.IsTimeLocked())
+	assert.False(t, txp.IsFinal(2000000, time.Unix(1600000000, 0)), "should not be final at lock time")
+	assert.True(t, txp.IsFinal(0, time.Unix(1600000001, 0)), "should be final after lock time")
+}
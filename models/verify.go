@@ -0,0 +1,185 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pavel-main/bws-go/utils"
+)
+
+const statusTemporary = "temporary"
+
+// Verify checks wallet copayers against trusted wallet public key, then creator signature
+// and signatures of accepting copayers, see VerifyCopayers, VerifyCreatorSignature and VerifyInputSignatures
+func (txp *TxProposal) Verify(wallet *Wallet, walletPubKey *btcec.PublicKey, net *chaincfg.Params) error {
+	if wallet == nil {
+		return errors.New("Wallet not specified")
+	}
+
+	if err := wallet.VerifyCopayers(walletPubKey); err != nil {
+		return err
+	}
+
+	if err := txp.VerifyCreatorSignature(wallet, net); err != nil {
+		return err
+	}
+
+	return txp.VerifyInputSignatures(wallet, net)
+}
+
+// VerifyCopayers checks that every copayer joined with invitation of wallet with public key known
+// to client, as server may add copayers, and that additional request keys were authorized by copayer
+func (w *Wallet) VerifyCopayers(walletPubKey *btcec.PublicKey) error {
+	if walletPubKey == nil {
+		return errors.New("Wallet public key not specified")
+	}
+
+	for _, copayer := range w.Copayers {
+		if !copayer.invited(walletPubKey) {
+			return fmt.Errorf("Copayer %s was not invited to wallet", copayer.Name)
+		}
+
+		for _, requestKey := range copayer.RequestPubKeys {
+			if requestKey.Key != copayer.RequestPubKey && !copayer.authorizes(requestKey.Key, requestKey.Signature) {
+				return fmt.Errorf("Request key %s was not authorized by copayer %s", requestKey.Key, copayer.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// VerifyCreatorSignature checks that published proposal was signed by request key of wallet copayer who created it,
+// request keys other than the one copayer joined with must be authorized by copayer XPub
+func (txp *TxProposal) VerifyCreatorSignature(wallet *Wallet, net *chaincfg.Params) error {
+	if wallet == nil {
+		return errors.New("Wallet not specified")
+	}
+
+	creator := wallet.Copayer(txp.CreatorID)
+	if creator == nil {
+		return fmt.Errorf("Proposal creator %s is not wallet copayer", txp.CreatorID)
+	}
+
+	// Temporary proposals are signed when published
+	if txp.Status == statusTemporary {
+		return nil
+	}
+
+	if txp.CreatorSignature == "" {
+		return errors.New("Proposal is not signed by creator")
+	}
+
+	key := creator.RequestPubKey
+	if txp.CreatorSignaturePubKey != "" && txp.CreatorSignaturePubKey != creator.RequestPubKey {
+		key = txp.CreatorSignaturePubKey
+		authorized := creator.authorizes(key, txp.CreatorSignaturePubKeySig)
+		for _, requestKey := range creator.RequestPubKeys {
+			if requestKey.Key == key && creator.authorizes(key, requestKey.Signature) {
+				authorized = true
+			}
+		}
+
+		if !authorized {
+			return fmt.Errorf("Proposal signing key does not belong to creator %s", creator.Name)
+		}
+	}
+
+	pubKey, err := parsePubKey(key)
+	if err != nil {
+		return err
+	}
+
+	signature, err := utils.ToBytes(txp.CreatorSignature)
+	if err != nil {
+		return err
+	}
+
+	txBytes, err := txp.Serialize(net)
+	if err != nil {
+		return err
+	}
+
+	valid, err := utils.VerifyMessage([]byte(utils.ToHex(txBytes)), signature, pubKey)
+	if err != nil || !valid {
+		return fmt.Errorf("Invalid proposal signature of creator %s", creator.Name)
+	}
+
+	return nil
+}
+
+// VerifyInputSignatures checks input signatures of accepting copayers against keys derived
+// from their account XPubs, which must match wallet copayer list checked by VerifyCopayers
+func (txp *TxProposal) VerifyInputSignatures(wallet *Wallet, net *chaincfg.Params) error {
+	if wallet == nil {
+		return errors.New("Wallet not specified")
+	}
+
+	tx, err := txp.ToTransaction(net)
+	if err != nil {
+		return err
+	}
+
+	for _, action := range txp.Actions {
+		if action.Type != ActionAccept {
+			continue
+		}
+
+		copayer := wallet.Copayer(action.CopayerID)
+		if copayer == nil {
+			return fmt.Errorf("Signer %s is not wallet copayer", action.CopayerID)
+		}
+
+		if action.XPub != copayer.XPubKey {
+			return fmt.Errorf("Signing key of copayer %s does not match wallet", copayer.Name)
+		}
+
+		if len(action.Signatures) != len(txp.Inputs) {
+			return fmt.Errorf("Copayer %s signed %d of %d inputs", copayer.Name, len(action.Signatures), len(txp.Inputs))
+		}
+
+		for idx, input := range txp.Inputs {
+			pubKey, err := action.PublicKey(input.Path)
+			if err != nil {
+				return err
+			}
+
+			hash, err := txp.signatureHash(tx, net, idx)
+			if err != nil {
+				return err
+			}
+
+			if !verifySignature(action.Signatures[idx], hash, pubKey) {
+				return fmt.Errorf("Invalid signature of copayer %s for input %d", copayer.Name, idx)
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifySignature reports whether hex-encoded DER signature of hash is valid
+func verifySignature(signature string, hash []byte, pubKey *btcec.PublicKey) bool {
+	bytes, err := utils.ToBytes(signature)
+	if err != nil {
+		return false
+	}
+
+	parsed, err := btcec.ParseDERSignature(bytes, btcec.S256())
+	if err != nil {
+		return false
+	}
+
+	return parsed.Verify(hash, pubKey)
+}
+
+func parsePubKey(key string) (*btcec.PublicKey, error) {
+	bytes, err := utils.ToBytes(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return btcec.ParsePubKey(bytes, btcec.S256())
+}
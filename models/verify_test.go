@@ -0,0 +1,179 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/pavel-main/bws-go/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockWalletKey is private key of wallet invitation secret
+var mockWalletKey, _ = btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{9}, 32))
+
+// invite signs copayer with wallet invitation key
+func invite(copayer *Copayer) *Copayer {
+	signature, _ := utils.SignMessage(copayer.hash(), mockWalletKey)
+	copayer.Signature = utils.ToHex(signature)
+	return copayer
+}
+
+// authorize signs request key with key derived from copayer XPub
+func authorize(copayer *mockCopayer, key string) string {
+	private, _ := copayer.keys(requestKeyAuthPath)
+	signature, _ := utils.SignMessage([]byte(key), private)
+	return utils.ToHex(signature)
+}
+
+// mockSignedTxp returns 2-of-3 proposal created and signed by the first copayer, and wallet of copayers
+func mockSignedTxp(t *testing.T) (*TxProposal, *Wallet, []*mockCopayer) {
+	copayers := newMockCopayers(3)
+	wallet := &Wallet{M: 2, N: 3}
+	for _, copayer := range copayers {
+		_, requestKey := copayer.keys("m/1/0")
+		wallet.Copayers = append(wallet.Copayers, invite(&Copayer{
+			ID:            copayer.id,
+			Name:          "copayer " + copayer.id,
+			XPubKey:       copayer.xpub(),
+			RequestPubKey: utils.ToHex(requestKey.SerializeCompressed()),
+		}))
+	}
+
+	input := mockMultisigInput(copayers, 2, "m/0/1", "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", 100000)
+	txp := mockUnsignedTxp(2, 3, input)
+	txp.CreatorID = "a"
+	txp.Status = "pending"
+
+	requestKey, _ := copayers[0].keys("m/1/0")
+	signature, err := txp.ProposalSignature(requestKey, net)
+	assert.NoError(t, err, "should sign proposal")
+	txp.CreatorSignature = utils.ToHex(signature)
+
+	accept(t, txp, copayers[0])
+	return txp, wallet, copayers
+}
+
+func TestVerifyCreatorSignature(t *testing.T) {
+	txp, wallet, copayers := mockSignedTxp(t)
+	assert.NoError(t, txp.Verify(wallet, mockWalletKey.PubKey(), net), "should verify untampered proposal")
+
+	// Amount changed after creator signed
+	txp.Amount--
+	assert.Error(t, txp.VerifyCreatorSignature(wallet, net), "should detect tampered proposal")
+	txp.Amount++
+
+	txp.CreatorID = "b"
+	assert.Error(t, txp.VerifyCreatorSignature(wallet, net), "should detect signature of other copayer")
+
+	txp.CreatorID = "x"
+	assert.Error(t, txp.VerifyCreatorSignature(wallet, net), "should reject creator outside of wallet")
+
+	txp.CreatorID = "a"
+	otherPrivKey, otherKey := copayers[0].keys("m/1/1")
+	signature, _ := txp.ProposalSignature(otherPrivKey, net)
+	txp.CreatorSignature = utils.ToHex(signature)
+	txp.CreatorSignaturePubKey = utils.ToHex(otherKey.SerializeCompressed())
+	assert.Error(t, txp.VerifyCreatorSignature(wallet, net), "should reject key not registered by creator")
+
+	wallet.Copayers[0].RequestPubKeys = []*RequestPubKey{{Key: txp.CreatorSignaturePubKey}}
+	assert.Error(t, txp.VerifyCreatorSignature(wallet, net), "should reject key listed by server without authorization")
+
+	wallet.Copayers[0].RequestPubKeys[0].Signature = authorize(copayers[1], txp.CreatorSignaturePubKey)
+	assert.Error(t, txp.VerifyCreatorSignature(wallet, net), "should reject key authorized by other copayer")
+
+	wallet.Copayers[0].RequestPubKeys[0].Signature = authorize(copayers[0], txp.CreatorSignaturePubKey)
+	assert.NoError(t, txp.VerifyCreatorSignature(wallet, net), "should verify with registered signing key")
+
+	wallet.Copayers[0].RequestPubKeys = nil
+	txp.CreatorSignaturePubKeySig = authorize(copayers[0], txp.CreatorSignaturePubKey)
+	assert.NoError(t, txp.VerifyCreatorSignature(wallet, net), "should verify with signing key authorized in proposal")
+
+	txp.CreatorSignaturePubKey = ""
+	txp.CreatorSignature = ""
+	assert.Error(t, txp.VerifyCreatorSignature(wallet, net), "should require signature of published proposal")
+
+	txp.Status = "temporary"
+	assert.NoError(t, txp.VerifyCreatorSignature(wallet, net), "should not require signature of temporary proposal")
+}
+
+func TestVerifyInputSignatures(t *testing.T) {
+	txp, wallet, copayers := mockSignedTxp(t)
+	accept(t, txp, copayers[1])
+	assert.NoError(t, txp.VerifyInputSignatures(wallet, net), "should verify signatures of both copayers")
+
+	// XPub replaced by server
+	txp.Actions[1].XPub = newMockCopayers(4)[3].xpub()
+	assert.Error(t, txp.VerifyInputSignatures(wallet, net), "should reject key not matching wallet")
+
+	txp.Actions[1].XPub = copayers[1].xpub()
+	txp.Actions[1].Signatures[0] = txp.Actions[0].Signatures[0]
+	assert.Error(t, txp.VerifyInputSignatures(wallet, net), "should reject signature of other copayer")
+
+	txp.Actions[1].Signatures = nil
+	assert.Error(t, txp.VerifyInputSignatures(wallet, net), "should reject missing signatures")
+
+	txp.Actions[1].CopayerID = "x"
+	assert.Error(t, txp.VerifyInputSignatures(wallet, net), "should reject signer outside of wallet")
+
+	txp.Actions = txp.Actions[:1]
+	txp.Fee++
+	assert.Error(t, txp.VerifyInputSignatures(wallet, net), "should detect tampered proposal")
+}
+
+func TestVerifyCopayers(t *testing.T) {
+	txp, wallet, copayers := mockSignedTxp(t)
+	walletKey := mockWalletKey.PubKey()
+	assert.NoError(t, wallet.VerifyCopayers(walletKey), "should verify invited copayers")
+	assert.Error(t, wallet.VerifyCopayers(nil), "should require wallet key")
+
+	other, _ := btcec.NewPrivateKey(btcec.S256())
+	assert.Error(t, wallet.VerifyCopayers(other.PubKey()), "should reject copayers of other wallet")
+
+	// Copayer added by server
+	intruder := newMockCopayers(4)[3]
+	wallet.Copayers[1].XPubKey = intruder.xpub()
+	assert.Error(t, wallet.VerifyCopayers(walletKey), "should reject replaced XPub")
+	assert.Error(t, txp.Verify(wallet, walletKey, net), "should not verify proposal of tampered wallet")
+	wallet.Copayers[1].XPubKey = copayers[1].xpub()
+
+	_, requestKey := copayers[1].keys("m/1/1")
+	key := utils.ToHex(requestKey.SerializeCompressed())
+	wallet.Copayers[1].RequestPubKeys = []*RequestPubKey{{Key: wallet.Copayers[1].RequestPubKey}, {Key: key}}
+	assert.Error(t, wallet.VerifyCopayers(walletKey), "should reject unauthorized request key")
+
+	wallet.Copayers[1].RequestPubKeys[1].Signature = authorize(copayers[1], key)
+	assert.NoError(t, wallet.VerifyCopayers(walletKey), "should accept authorized request key")
+}
+
+func TestVerifyInputSignaturesWitness(t *testing.T) {
+	copayers := newMockCopayers(3)
+	input := mockMultisigInput(copayers, 2, "m/0/4", "0d5e1687d8f3dc24532798f25dcd9719d7148766b4516ac81e8e33bda54979b4", 100000)
+	redeemScript, _ := (&TxProposal{WalletM: 2}).BuildRedeemScript(input, net)
+	hash := sha256.Sum256(redeemScript)
+	address, _ := btcutil.NewAddressWitnessScriptHash(hash[:], net)
+	script, _ := txscript.PayToAddrScript(address)
+	input.Address = address.EncodeAddress()
+	input.ScriptPubKey = utils.ToHex(script)
+
+	wallet := &Wallet{M: 2, N: 3, AddressType: AddressTypeP2WSH}
+	for _, copayer := range copayers {
+		wallet.Copayers = append(wallet.Copayers, &Copayer{ID: copayer.id, XPubKey: copayer.xpub()})
+	}
+
+	txp := mockUnsignedTxp(2, 3, input)
+	txp.AddressType = AddressTypeP2WSH
+	accept(t, txp, copayers[0])
+	accept(t, txp, copayers[2])
+	assert.NoError(t, txp.VerifyInputSignatures(wallet, net), "should verify witness signatures")
+
+	_, err := txp.Finalize(net)
+	assert.NoError(t, err, "should finalize witness transaction with valid signatures")
+
+	// Witness signatures commit to input amount
+	input.Satoshis++
+	assert.Error(t, txp.VerifyInputSignatures(wallet, net), "should reject signatures of other amount")
+}
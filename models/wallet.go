@@ -1,17 +1,56 @@
 package models
 
+// Address types of wallet coins
+const (
+	AddressTypeP2PKH  = "P2PKH"
+	AddressTypeP2SH   = "P2SH"
+	AddressTypeP2WPKH = "P2WPKH"
+	AddressTypeP2WSH  = "P2WSH"
+)
+
 // Wallet represents generic wallet data structure
 type Wallet struct {
-	ID                 string `json:"id"`
-	Version            string `json:"version"`
-	CreatedOn          uint   `json:"createdOn"`
-	M                  uint   `json:"m"`
-	N                  uint   `json:"n"`
-	SingleAddress      bool   `json:"singleAddress"`
-	Status             string `json:"status"`
-	PubKey             string `json:"pubKey"`
-	Coin               string `json:"coin"`
-	Network            string `json:"network"`
-	DerivationStrategy string `json:"derivationStrategy"`
-	AddressType        string `json:"addressType"`
+	ID                 string     `json:"id"`
+	Name               string     `json:"name,omitempty"`
+	Version            string     `json:"version"`
+	CreatedOn          uint       `json:"createdOn"`
+	M                  uint       `json:"m"`
+	N                  uint       `json:"n"`
+	SingleAddress      bool       `json:"singleAddress"`
+	Status             string     `json:"status"`
+	PubKey             string     `json:"pubKey"`
+	Coin               string     `json:"coin"`
+	Network            string     `json:"network"`
+	DerivationStrategy string     `json:"derivationStrategy"`
+	AddressType        string     `json:"addressType"`
+	Copayers           []*Copayer `json:"copayers,omitempty"`
+}
+
+// Copayer returns wallet copayer by ID or nil if not found
+func (w *Wallet) Copayer(id string) *Copayer {
+	for _, copayer := range w.Copayers {
+		if copayer.ID == id {
+			return copayer
+		}
+	}
+
+	return nil
+}
+
+// EffectiveAddressType returns address type of wallet coins, BWS omits it for wallets created without explicit one
+func (w *Wallet) EffectiveAddressType() string {
+	if w.AddressType != "" {
+		return w.AddressType
+	}
+
+	return defaultAddressType(int(w.N))
+}
+
+// defaultAddressType returns address type of wallets created without explicit one
+func defaultAddressType(n int) string {
+	if n > 1 {
+		return AddressTypeP2SH
+	}
+
+	return AddressTypeP2PKH
 }
@@ -2,5 +2,8 @@ package models
 
 // WalletStatus represents wallet status response
 type WalletStatus struct {
-	Wallet *Wallet `json:"wallet,omitempty"`
+	Wallet      *Wallet       `json:"wallet,omitempty"`
+	Balance     *Balance      `json:"balance,omitempty"`
+	PendingTxps []*TxProposal `json:"pendingTxps,omitempty"`
+	Preferences *Preferences  `json:"preferences,omitempty"`
 }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletStatusDecode(t *testing.T) {
+	data := `{
+		"wallet": {
+			"id": "123e4567-e89b-12d3-a456-426655440000",
+			"m": 2,
+			"n": 2,
+			"status": "complete",
+			"copayers": [
+				{
+					"id": "a1",
+					"name": "Alice",
+					"xPubKey": "tpubAlice",
+					"requestPubKey": "02aa",
+					"requestPubKeys": [{"key": "02aa", "signature": "3045"}],
+					"createdOn": 1538469762,
+					"customData": "{\"iv\":\"...\"}"
+				},
+				{"id": "b2", "name": "Bob", "xPubKey": "tpubBob"}
+			]
+		},
+		"balance": {"totalAmount": 10000, "availableAmount": 4000, "lockedAmount": 6000},
+		"pendingTxps": [{"id": "txp1", "amount": 6000, "status": "pending"}],
+		"preferences": {"email": "alice@example.com", "unit": "bit", "tokenAddresses": ["0xabc"]}
+	}`
+
+	status := &WalletStatus{}
+	assert.Nil(t, json.Unmarshal([]byte(data), status), "should decode status")
+
+	assert.Len(t, status.Wallet.Copayers, 2, "should decode copayers")
+	alice := status.Wallet.Copayer("a1")
+	assert.NotNil(t, alice, "should find copayer by ID")
+	assert.Equal(t, "Alice", alice.Name)
+	assert.Equal(t, "tpubAlice", alice.XPubKey)
+	assert.Equal(t, []*RequestPubKey{{Key: "02aa", Signature: "3045"}}, alice.RequestPubKeys)
+	assert.Equal(t, uint(1538469762), alice.CreatedOn)
+	assert.Nil(t, status.Wallet.Copayer("c3"), "should not find unknown copayer")
+
+	assert.Equal(t, uint64(4000), status.Balance.AvailableAmount, "should decode balance")
+	assert.Equal(t, "txp1", status.PendingTxps[0].ID, "should decode pending proposals")
+	assert.Equal(t, []string{"0xabc"}, status.Preferences.TokenAddresses, "should decode preferences")
+}
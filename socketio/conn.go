@@ -0,0 +1,374 @@
+// Package socketio implements minimal Socket.IO v2 client over Engine.IO v3 long-polling transport
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned when connection was closed by either side
+var ErrClosed = errors.New("Connection closed")
+
+const (
+	maxConnectPolls     = 5 // Polls waiting for Socket.IO connect packet
+	defaultPingInterval = 25 * time.Second
+)
+
+type handshake struct {
+	SID          string `json:"sid"`
+	PingInterval int    `json:"pingInterval"`
+	PingTimeout  int    `json:"pingTimeout"`
+}
+
+// Conn represents Socket.IO connection
+type Conn struct {
+	endpoint     *url.URL
+	client       *http.Client
+	sid          string
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+	connected    bool
+	opening      bool
+	events       chan *Event
+	pending      []*Event
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	mutex  sync.Mutex
+	err    error
+	pong   time.Time
+}
+
+// Dial connects to Socket.IO endpoint (e.g. https://example.com/socket.io/) and waits for connect packet.
+// Context bounds handshake only, once connected its expiration does not affect connection.
+func Dial(ctx context.Context, endpoint string, client *http.Client) (*Conn, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	c := new(Conn)
+	c.endpoint = u
+	c.client = client
+	c.events = make(chan *Event, 64)
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.cancel()
+		case <-done:
+		}
+	}()
+
+	err = c.open()
+	close(done)
+	if err != nil {
+		c.cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, err
+	}
+
+	c.pong = time.Now()
+	go c.pollLoop()
+	go c.pingLoop()
+	return c, nil
+}
+
+// Events returns channel with incoming events, closed when connection is closed
+func (c *Conn) Events() <-chan *Event {
+	return c.events
+}
+
+// Emit sends event to server
+func (c *Conn) Emit(name string, args ...interface{}) error {
+	packet, err := EncodeEvent(name, args...)
+	if err != nil {
+		return err
+	}
+
+	return c.send(packet)
+}
+
+// Err returns reason of connection closure
+func (c *Conn) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.err
+}
+
+// Close closes connection, notifying server
+func (c *Conn) Close() error {
+	c.mutex.Lock()
+	open := c.err == nil
+	if open {
+		c.err = ErrClosed
+	}
+	c.mutex.Unlock()
+
+	if open {
+		c.send(string(PacketClose))
+	}
+
+	c.cancel()
+	return nil
+}
+
+// open performs Engine.IO handshake and waits for Socket.IO connect packet
+func (c *Conn) open() error {
+	packets, err := c.poll()
+	if err != nil {
+		return err
+	}
+
+	if len(packets) == 0 || len(packets[0]) == 0 || packets[0][0] != PacketOpen {
+		return errors.New("Invalid handshake")
+	}
+
+	info := &handshake{}
+	if err := json.Unmarshal([]byte(packets[0][1:]), info); err != nil {
+		return err
+	}
+
+	if info.SID == "" {
+		return errors.New("Session ID not specified")
+	}
+
+	c.sid = info.SID
+	c.pingInterval = time.Duration(info.PingInterval) * time.Millisecond
+	c.pingTimeout = time.Duration(info.PingTimeout) * time.Millisecond
+
+	if c.pingInterval <= 0 {
+		c.pingInterval = defaultPingInterval
+	}
+
+	c.opening = true
+	defer func() { c.opening = false }()
+
+	packets = packets[1:]
+	for attempt := 0; ; attempt++ {
+		for _, packet := range packets {
+			if err := c.handle(packet); err != nil {
+				return err
+			}
+		}
+
+		if c.connected {
+			return nil
+		}
+
+		if attempt >= maxConnectPolls {
+			return errors.New("Socket.IO connect packet not received")
+		}
+
+		if packets, err = c.poll(); err != nil {
+			return err
+		}
+	}
+}
+
+// handle processes single Engine.IO packet
+func (c *Conn) handle(packet string) error {
+	if len(packet) == 0 {
+		return nil
+	}
+
+	switch packet[0] {
+	case PacketPong:
+		c.mutex.Lock()
+		c.pong = time.Now()
+		c.mutex.Unlock()
+	case PacketPing:
+		return c.send(string(PacketPong) + packet[1:])
+	case PacketClose:
+		return ErrClosed
+	case PacketMessage:
+		return c.handleMessage(packet)
+	}
+
+	return nil
+}
+
+func (c *Conn) handleMessage(packet string) error {
+	if len(packet) < 2 {
+		return nil
+	}
+
+	switch packet[1] {
+	case SocketConnect:
+		c.connected = true
+	case SocketDisconnect:
+		return ErrClosed
+	case SocketError:
+		return fmt.Errorf("Socket.IO error: %s", packet[2:])
+	case SocketEvent:
+		event, err := DecodeEvent(packet)
+		if err != nil {
+			return err
+		}
+
+		// Events received during handshake are delivered once loops start
+		if c.opening {
+			c.pending = append(c.pending, event)
+			return nil
+		}
+
+		return c.deliver(event)
+	}
+
+	return nil
+}
+
+func (c *Conn) deliver(event *Event) error {
+	select {
+	case c.events <- event:
+		return nil
+	case <-c.ctx.Done():
+		return c.Err()
+	}
+}
+
+func (c *Conn) pollLoop() {
+	defer close(c.events)
+
+	for _, event := range c.pending {
+		if err := c.deliver(event); err != nil {
+			c.fail(err)
+			return
+		}
+	}
+
+	for {
+		packets, err := c.poll()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		for _, packet := range packets {
+			if err := c.handle(packet); err != nil {
+				c.fail(err)
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) pingLoop() {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		c.mutex.Lock()
+		silence := time.Since(c.pong)
+		c.mutex.Unlock()
+
+		if silence > c.pingInterval+c.pingTimeout {
+			c.fail(errors.New("Ping timeout"))
+			return
+		}
+
+		if err := c.send(string(PacketPing)); err != nil {
+			c.fail(err)
+			return
+		}
+	}
+}
+
+// fail closes connection with the first error
+func (c *Conn) fail(err error) {
+	c.mutex.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.mutex.Unlock()
+	c.cancel()
+}
+
+// poll performs long-polling GET request
+func (c *Conn) poll() ([]string, error) {
+	req, err := http.NewRequestWithContext(c.ctx, "GET", c.url(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodePayload(body)
+}
+
+// send posts packets to server
+func (c *Conn) send(packets ...string) error {
+	req, err := http.NewRequestWithContext(c.ctx, "POST", c.url(), strings.NewReader(EncodePayload(packets...)))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
+	_, err = c.do(req)
+	return err
+}
+
+func (c *Conn) do(req *http.Request) (string, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		if c.ctx.Err() != nil {
+			return "", ErrClosed
+		}
+
+		return "", err
+	}
+
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Engine.IO error, status: %d, message: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return string(body), nil
+}
+
+func (c *Conn) url() string {
+	query := c.endpoint.Query()
+	query.Set("EIO", "3")
+	query.Set("transport", "polling")
+	query.Set("b64", "1")
+	query.Set("t", strconv.FormatInt(time.Now().UnixNano(), 36))
+	if c.sid != "" {
+		query.Set("sid", c.sid)
+	}
+
+	u := *c.endpoint
+	u.RawQuery = query.Encode()
+	return u.String()
+}
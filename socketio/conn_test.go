@@ -0,0 +1,93 @@
+package socketio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pavel-main/bws-go/socketio"
+	"github.com/pavel-main/bws-go/socketio/sockettest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConn(t *testing.T) {
+	server := sockettest.NewServer()
+	server.PingInterval = 50 * time.Millisecond
+	server.OnConnect = func(s *sockettest.Session) {
+		s.Emit("challenge", "nonce")
+	}
+	server.OnEvent = func(s *sockettest.Session, event *socketio.Event) {
+		var message string
+		event.Decode(0, &message)
+		s.Emit("echo", message)
+	}
+	defer server.Close()
+
+	conn, err := socketio.Dial(context.Background(), server.Endpoint, nil)
+	assert.NoError(t, err, "should connect")
+
+	event := <-conn.Events()
+	assert.Equal(t, "challenge", event.Name, "should receive event emitted on connect")
+
+	// Outlive a few ping intervals
+	time.Sleep(150 * time.Millisecond)
+	assert.NoError(t, conn.Emit("message", "hola"), "should emit event")
+
+	event = <-conn.Events()
+	assert.Equal(t, "echo", event.Name, "should receive reply")
+
+	var message string
+	assert.NoError(t, event.Decode(0, &message), "should decode argument")
+	assert.Equal(t, "hola", message, "arguments should match")
+
+	assert.NoError(t, conn.Close(), "should close connection")
+	_, ok := <-conn.Events()
+	assert.False(t, ok, "should close events channel")
+	assert.Equal(t, socketio.ErrClosed, conn.Err(), "should report closed connection")
+}
+
+func TestConnServerClose(t *testing.T) {
+	server := sockettest.NewServer()
+	defer server.Close()
+
+	conn, err := socketio.Dial(context.Background(), server.Endpoint, nil)
+	assert.NoError(t, err, "should connect")
+
+	server.CloseSessions()
+	_, ok := <-conn.Events()
+	assert.False(t, ok, "should close events channel")
+	assert.Error(t, conn.Err(), "should report closed connection")
+}
+
+func TestDialErrors(t *testing.T) {
+	server := sockettest.NewServer()
+	defer server.Close()
+
+	_, err := socketio.Dial(context.Background(), server.URL+"/socket.io/?EIO=4", nil)
+	assert.NoError(t, err, "should override protocol version")
+
+	_, err = socketio.Dial(context.Background(), "http://%zz", nil)
+	assert.Error(t, err, "should fail on invalid URL")
+
+	server.Close()
+	_, err = socketio.Dial(context.Background(), server.Endpoint, nil)
+	assert.Error(t, err, "should fail on unavailable server")
+}
+
+func TestDialCancel(t *testing.T) {
+	// Server accepting requests without ever answering them
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+	defer close(block)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := socketio.Dial(ctx, server.URL+"/socket.io/", nil)
+	assert.Equal(t, context.DeadlineExceeded, err, "should stop handshake on context expiration")
+}
@@ -0,0 +1,149 @@
+package socketio
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Engine.IO packet types
+const (
+	PacketOpen    = '0'
+	PacketClose   = '1'
+	PacketPing    = '2'
+	PacketPong    = '3'
+	PacketMessage = '4'
+	PacketUpgrade = '5'
+	PacketNoop    = '6'
+)
+
+// Socket.IO packet types, carried inside Engine.IO message packets
+const (
+	SocketConnect    = '0'
+	SocketDisconnect = '1'
+	SocketEvent      = '2'
+	SocketAck        = '3'
+	SocketError      = '4'
+)
+
+// Event represents Socket.IO event with JSON-encoded arguments
+type Event struct {
+	Name string
+	Args []json.RawMessage
+}
+
+// Decode unmarshals event argument by index
+func (e *Event) Decode(idx int, target interface{}) error {
+	if idx >= len(e.Args) {
+		return errors.New("Event argument not found")
+	}
+
+	return json.Unmarshal(e.Args[idx], target)
+}
+
+// EncodeEvent builds Engine.IO message packet carrying Socket.IO event
+func EncodeEvent(name string, args ...interface{}) (string, error) {
+	data, err := json.Marshal(append([]interface{}{name}, args...))
+	if err != nil {
+		return "", err
+	}
+
+	return string([]byte{PacketMessage, SocketEvent}) + string(data), nil
+}
+
+// DecodeEvent parses Socket.IO event from Engine.IO message packet
+func DecodeEvent(packet string) (*Event, error) {
+	if len(packet) < 2 || packet[0] != PacketMessage || packet[1] != SocketEvent {
+		return nil, errors.New("Not an event packet")
+	}
+
+	// Skip optional namespace and ack ID preceding JSON payload
+	data := packet[2:]
+	if idx := strings.IndexByte(data, '['); idx >= 0 {
+		data = data[idx:]
+	}
+
+	parts := []json.RawMessage{}
+	if err := json.Unmarshal([]byte(data), &parts); err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 0 {
+		return nil, errors.New("Event name not specified")
+	}
+
+	event := &Event{Args: parts[1:]}
+	if err := json.Unmarshal(parts[0], &event.Name); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// EncodePayload joins packets into Engine.IO v3 text payload, lengths are counted in UTF-16 units
+func EncodePayload(packets ...string) string {
+	var builder strings.Builder
+	for _, packet := range packets {
+		builder.WriteString(strconv.Itoa(jsLength(packet)))
+		builder.WriteByte(':')
+		builder.WriteString(packet)
+	}
+
+	return builder.String()
+}
+
+// DecodePayload splits Engine.IO v3 text payload into packets
+func DecodePayload(payload string) ([]string, error) {
+	packets := []string{}
+	for len(payload) > 0 {
+		sep := strings.IndexByte(payload, ':')
+		if sep <= 0 {
+			return nil, errors.New("Invalid payload length")
+		}
+
+		length, err := strconv.Atoi(payload[:sep])
+		if err != nil {
+			return nil, errors.New("Invalid payload length")
+		}
+
+		payload = payload[sep+1:]
+		end, ok := jsOffset(payload, length)
+		if !ok {
+			return nil, errors.New("Truncated payload")
+		}
+
+		packets = append(packets, payload[:end])
+		payload = payload[end:]
+	}
+
+	return packets, nil
+}
+
+// jsLength returns string length as JavaScript sees it
+func jsLength(s string) int {
+	length := 0
+	for _, r := range s {
+		length += len(utf16.Encode([]rune{r}))
+	}
+
+	return length
+}
+
+// jsOffset returns byte offset after the given number of UTF-16 units
+func jsOffset(s string, units int) (int, bool) {
+	offset := 0
+	for units > 0 {
+		if offset >= len(s) {
+			return 0, false
+		}
+
+		r, size := utf8.DecodeRuneInString(s[offset:])
+		units -= len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+
+	return offset, units == 0
+}
@@ -0,0 +1,50 @@
+package socketio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayload(t *testing.T) {
+	packets := []string{`0{"sid":"abc"}`, "40", `42["notification","héllo 😀"]`}
+	payload := EncodePayload(packets...)
+	assert.Equal(t, `14:0{"sid":"abc"}2:4029:42["notification","héllo 😀"]`, payload, "should count UTF-16 units")
+
+	decoded, err := DecodePayload(payload)
+	assert.NoError(t, err, "should decode payload")
+	assert.Equal(t, packets, decoded, "packets should match")
+}
+
+func TestPayloadErrors(t *testing.T) {
+	for _, payload := range []string{"abc", ":40", "x:40", "5:40"} {
+		_, err := DecodePayload(payload)
+		assert.Error(t, err, "should fail on %s", payload)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	packet, err := EncodeEvent("authorize", map[string]string{"message": "nonce"})
+	assert.NoError(t, err, "should encode event")
+	assert.Equal(t, `42["authorize",{"message":"nonce"}]`, packet, "packets should match")
+
+	event, err := DecodeEvent(packet)
+	assert.NoError(t, err, "should decode event")
+	assert.Equal(t, "authorize", event.Name, "names should match")
+
+	args := map[string]string{}
+	assert.NoError(t, event.Decode(0, &args), "should decode argument")
+	assert.Equal(t, "nonce", args["message"], "arguments should match")
+	assert.Error(t, event.Decode(1, &args), "should fail on missing argument")
+
+	event, err = DecodeEvent(`42/bws,7["challenge","nonce"]`)
+	assert.NoError(t, err, "should skip namespace and ack ID")
+	assert.Equal(t, "challenge", event.Name, "names should match")
+}
+
+func TestEventErrors(t *testing.T) {
+	for _, packet := range []string{"", "40", "42", "42[]", "42[1]", "42{"} {
+		_, err := DecodeEvent(packet)
+		assert.Error(t, err, "should fail on %s", packet)
+	}
+}
@@ -0,0 +1,205 @@
+// Package sockettest provides local Socket.IO stand-in server for tests, similar to net/http/httptest
+package sockettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pavel-main/bws-go/socketio"
+)
+
+// Server serves Engine.IO v3 long-polling transport
+type Server struct {
+	*httptest.Server
+
+	// Endpoint is Socket.IO URL to pass to socketio.Dial
+	Endpoint string
+
+	// PingInterval is announced in handshake and limits long-polling duration
+	PingInterval time.Duration
+
+	// OnConnect is called when new session is established
+	OnConnect func(s *Session)
+
+	// OnEvent is called for every event emitted by client
+	OnEvent func(s *Session, event *socketio.Event)
+
+	mutex    sync.Mutex
+	sessions map[string]*Session
+	counter  int
+}
+
+// Session represents single client connection
+type Session struct {
+	ID     string
+	queue  chan string
+	closed chan struct{}
+	once   sync.Once
+}
+
+// Emit sends event to client
+func (s *Session) Emit(name string, args ...interface{}) error {
+	packet, err := socketio.EncodeEvent(name, args...)
+	if err != nil {
+		return err
+	}
+
+	s.queue <- packet
+	return nil
+}
+
+// Close disconnects client, following requests fail
+func (s *Session) Close() {
+	s.once.Do(func() {
+		close(s.closed)
+	})
+}
+
+// NewServer starts Socket.IO stand-in, callbacks may be set before first connection
+func NewServer() *Server {
+	s := &Server{
+		PingInterval: time.Second,
+		sessions:     map[string]*Session{},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.Endpoint = s.URL + "/socket.io/"
+	return s
+}
+
+// Sessions returns number of established sessions
+func (s *Server) Sessions() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.counter
+}
+
+// CloseSessions disconnects all clients
+func (s *Server) CloseSessions() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, session := range s.sessions {
+		session.Close()
+	}
+}
+
+func (s *Server) serve(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Query().Get("EIO") != "3" || req.URL.Query().Get("transport") != "polling" {
+		http.Error(res, "Unsupported transport", http.StatusBadRequest)
+		return
+	}
+
+	sid := req.URL.Query().Get("sid")
+	if sid == "" {
+		s.handshake(res)
+		return
+	}
+
+	s.mutex.Lock()
+	session, ok := s.sessions[sid]
+	s.mutex.Unlock()
+
+	if !ok {
+		http.Error(res, "Session ID unknown", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case <-session.closed:
+		http.Error(res, "Session closed", http.StatusBadRequest)
+		return
+	default:
+	}
+
+	if req.Method == "POST" {
+		s.receive(res, req, session)
+		return
+	}
+
+	s.poll(res, session)
+}
+
+func (s *Server) handshake(res http.ResponseWriter) {
+	s.mutex.Lock()
+	s.counter++
+	session := &Session{
+		ID:     fmt.Sprintf("session-%d", s.counter),
+		queue:  make(chan string, 256),
+		closed: make(chan struct{}),
+	}
+	s.sessions[session.ID] = session
+	s.mutex.Unlock()
+
+	info, _ := json.Marshal(map[string]interface{}{
+		"sid":          session.ID,
+		"upgrades":     []string{},
+		"pingInterval": int(s.PingInterval / time.Millisecond),
+		"pingTimeout":  int(s.PingInterval / time.Millisecond),
+	})
+
+	connect := string([]byte{socketio.PacketMessage, socketio.SocketConnect})
+	fmt.Fprint(res, socketio.EncodePayload(string(socketio.PacketOpen)+string(info), connect))
+
+	if s.OnConnect != nil {
+		s.OnConnect(session)
+	}
+}
+
+func (s *Server) poll(res http.ResponseWriter, session *Session) {
+	packets := []string{}
+	timeout := time.NewTimer(s.PingInterval)
+	defer timeout.Stop()
+
+	select {
+	case packet := <-session.queue:
+		packets = append(packets, packet)
+	case <-session.closed:
+		packets = append(packets, string(socketio.PacketClose))
+	case <-timeout.C:
+		packets = append(packets, string(socketio.PacketNoop))
+	}
+
+	// Flush everything queued meanwhile
+	for len(session.queue) > 0 {
+		packets = append(packets, <-session.queue)
+	}
+
+	fmt.Fprint(res, socketio.EncodePayload(packets...))
+}
+
+func (s *Server) receive(res http.ResponseWriter, req *http.Request, session *Session) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	packets, err := socketio.DecodePayload(string(body))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, packet := range packets {
+		switch {
+		case strings.HasPrefix(packet, string(socketio.PacketPing)):
+			session.queue <- string(socketio.PacketPong) + packet[1:]
+		case strings.HasPrefix(packet, string(socketio.PacketClose)):
+			session.Close()
+		default:
+			event, err := socketio.DecodeEvent(packet)
+			if err == nil && s.OnEvent != nil {
+				s.OnEvent(session, event)
+			}
+		}
+	}
+
+	fmt.Fprint(res, "ok")
+}
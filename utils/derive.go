@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const hardening = "'"
+
+// DerivePath derives child of extended key by BIP32 path relative to it, e.g. m/0/5
+func DerivePath(key *hdkeychain.ExtendedKey, path string) (*hdkeychain.ExtendedKey, error) {
+	current := key
+	for _, part := range strings.Split(path, "/") {
+		if part == "m" {
+			continue
+		}
+
+		idx := part
+		hardened := false
+		if strings.Contains(part, hardening) {
+			idx = strings.Replace(part, hardening, "", -1)
+			hardened = true
+		}
+
+		id, err := strconv.ParseUint(idx, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		index := uint32(id)
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+
+		child, err := current.Child(index)
+		if err != nil {
+			return nil, err
+		}
+
+		current = child
+	}
+
+	return current, nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerivePath(t *testing.T) {
+	master, _ := hdkeychain.NewMaster(bytes.Repeat([]byte{1}, 32), &chaincfg.TestNet3Params)
+
+	expected, _ := master.Child(hdkeychain.HardenedKeyStart + 44)
+	expected, _ = expected.Child(5)
+	child, err := DerivePath(master, "m/44'/5")
+	assert.NoError(t, err, "should derive path")
+	assert.Equal(t, expected.String(), child.String(), "should derive hardened and normal children")
+
+	// Public derivation matches private one
+	public, _ := master.Neuter()
+	publicChild, err := DerivePath(public, "m/0/7")
+	assert.NoError(t, err, "should derive public path")
+	privateChild, _ := DerivePath(master, "0/7")
+	expectedKey, _ := privateChild.ECPubKey()
+	publicKey, _ := publicChild.ECPubKey()
+	assert.Equal(t, expectedKey.SerializeCompressed(), publicKey.SerializeCompressed(), "should derive the same public key")
+
+	_, err = DerivePath(public, "m/0'")
+	assert.Error(t, err, "should not derive hardened child of public key")
+
+	_, err = DerivePath(master, "m/x")
+	assert.Error(t, err, "should reject invalid path")
+}
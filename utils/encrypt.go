@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	saltSize         = 16
+	keySize          = 32
+	kdfIterations    = 100000
+	encryptedVersion = 1
+)
+
+// Encrypt encrypts data with AES-256-GCM using a key derived from passphrase with PBKDF2
+func Encrypt(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	result := []byte{encryptedVersion}
+	result = append(result, salt...)
+	result = append(result, nonce...)
+	return aead.Seal(result, nonce, data, nil), nil
+}
+
+// Decrypt decrypts data produced by Encrypt
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	if len(data) < 1+saltSize || data[0] != encryptedVersion {
+		return nil, errors.New("Invalid encrypted data")
+	}
+
+	salt := data[1 : 1+saltSize]
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := data[1+saltSize:]
+	if len(rest) < aead.NonceSize() {
+		return nil, errors.New("Invalid encrypted data")
+	}
+
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("Invalid passphrase or corrupted data")
+	}
+
+	return plain, nil
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key := pbkdf2.Key([]byte(passphrase), salt, kdfIterations, keySize, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	data := []byte("hola")
+	encrypted, err := Encrypt(data, "secret")
+	assert.NoError(t, err, "should encrypt data")
+	assert.NotContains(t, string(encrypted), "hola", "should not contain plain text")
+
+	decrypted, err := Decrypt(encrypted, "secret")
+	assert.NoError(t, err, "should decrypt data")
+	assert.Equal(t, data, decrypted, "should return original data")
+}
+
+func TestDecryptErrors(t *testing.T) {
+	encrypted, _ := Encrypt([]byte("hola"), "secret")
+
+	_, err := Decrypt(encrypted, "wrong")
+	assert.Error(t, err, "should fail on wrong passphrase")
+
+	_, err = Decrypt(encrypted[:10], "secret")
+	assert.Error(t, err, "should fail on truncated data")
+
+	_, err = Decrypt(nil, "secret")
+	assert.Error(t, err, "should fail on empty data")
+}